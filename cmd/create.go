@@ -17,27 +17,50 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
-	"github.com/Azure/draft/pkg/draft/draftpath"
 	"github.com/Azure/draft/pkg/draft/pack"
 	"github.com/spf13/cobra"
+
+	"github.com/rodcloutier/draft-packs/pkg/downloader"
+	"github.com/rodcloutier/draft-packs/pkg/draftpath"
+	. "github.com/rodcloutier/draft-packs/pkg/getter"
+	"github.com/rodcloutier/draft-packs/pkg/packutil"
+	"github.com/rodcloutier/draft-packs/pkg/repo"
 )
 
 const packCreateDesc = `
-This command creates a new pack. A starting pack must be specified.  It will
-then take the starting pack and copy it to the new pack directory named with
-the supplied name parameter.
+This command creates a new pack in a new directory named with the supplied
+name parameter.
+
+If a starting pack is specified with --starter, it will take the starting pack
+and copy it to the new pack directory. The starter is looked up, in order, as:
+
+  - a local pack directory or pack archive
+  - a pack installed in $DRAFT_HOME/packs
+  - a repository reference, REPO/NAME[@VERSION], downloaded from the repository
+
+Every reference to the starter name in the files of the new pack is replaced
+by the new name. Placeholders declared by the starter in its Placeholders.yaml
+file, and referenced as [[ NAME ]] in its files, are substituted with the
+values given with --set, or with their default values.
+
+Without a starter, a minimal pack is generated with a chart (deployment,
+service and ingress), a placeholder Dockerfile and a detect script to be
+completed.
 
 A path can also be supplied for the pack destination
 `
 
 type packCreateCmd struct {
-	home draftpath.Home
-	name string
-	dest string
-	pack string
+	home   draftpath.Home
+	name   string
+	dest   string
+	pack   string
+	values []string
 }
 
 func init() {
@@ -56,56 +79,118 @@ func init() {
 		},
 	}
 
-	cc.home = draftpath.Home(homePath())
+	cc.home = draftpath.NewHome(homePath())
 
 	f := cmd.Flags()
 	f.StringVarP(&cc.dest, "destination", "d", ".", "location to write the pack")
-	f.StringVarP(&cc.pack, "starter", "p", "", "name of the pack scaffold to use as a base")
+	f.StringVarP(&cc.pack, "starter", "p", "", "path, installed pack or REPO/NAME[@VERSION] of the pack to use as a base")
+	f.StringArrayVar(&cc.values, "set", []string{}, "set a placeholder declared by the starter pack (can specify multiple): NAME=VALUE")
 
 	RootCmd.AddCommand(cmd)
 }
 
-// TODO allow to not specify a pack, it would create a basic pack with the common files
-// and empty files needed
-
 func (c *packCreateCmd) run() error {
 
 	if c.pack == "" {
-		return errors.New("Must specify pack to start from using --starter")
+		if _, err := packutil.Create(c.name, c.dest); err != nil {
+			return err
+		}
+		fmt.Printf("--> Pack ready to be modified and packaged\n")
+		return nil
 	}
 
-	// Check that the starter pack exists
-	packs, err := pack.Builtins()
+	values, err := parseValues(c.values)
 	if err != nil {
 		return err
 	}
-	_, ok := packs[c.pack]
-	if !ok {
-		return errors.New("Unknown pack specified")
-	}
 
-	// Create the pack
-	packPath := filepath.Join(c.home.Packs(), c.pack)
-	p, err := pack.Load(packPath)
+	// Locate the starter pack
+	packPath, cleanup, err := c.locateStarter()
 	if err != nil {
 		return err
 	}
+	defer cleanup()
 
-	// Rename the new pack
-	p.Chart.Metadata.Name = c.name
+	// Make sure the starter is a valid pack
+	if _, err := pack.Load(packPath); err != nil {
+		return err
+	}
 
-	// Create the destination directory
 	destPath := filepath.Join(c.dest, c.name)
-	err = os.MkdirAll(destPath, os.FileMode(0755))
-	if err != nil {
-		return err
+	if _, err := os.Stat(destPath); err == nil {
+		return fmt.Errorf("%s already exists", destPath)
 	}
 
-	// Save the new pack
-	err = p.SaveDir(destPath, true)
-	if err != nil {
+	// Copy the starter and make it the new pack
+	if err := packutil.Copy(packPath, destPath); err != nil {
+		os.RemoveAll(destPath)
+		return err
+	}
+	if err := packutil.Customize(destPath, c.name, values); err != nil {
+		os.RemoveAll(destPath)
 		return err
 	}
 	fmt.Printf("--> Pack ready to be modified and packaged\n")
 	return nil
 }
+
+// parseValues parses the NAME=VALUE placeholder values.
+func parseValues(values []string) (map[string]string, error) {
+	m := map[string]string{}
+	for _, v := range values {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid placeholder value %q, expected NAME=VALUE", v)
+		}
+		m[kv[0]] = kv[1]
+	}
+	return m, nil
+}
+
+// locateStarter finds the starter pack and returns its path along with a
+// function releasing any temporary file created to hold it.
+//
+// The starter is resolved as a local path, then as an installed pack and
+// finally as a REPO/NAME[@VERSION] repository reference.
+func (c *packCreateCmd) locateStarter() (string, func(), error) {
+	noop := func() {}
+
+	if _, err := os.Stat(c.pack); err == nil {
+		abs, err := filepath.Abs(c.pack)
+		return abs, noop, err
+	}
+
+	// Packs installed from a repository are stored as repo-name
+	for _, name := range []string{c.pack, strings.Replace(c.pack, "/", "-", -1)} {
+		if dir, err := repo.FindPackDir(c.home.Packs(), name); err == nil {
+			return filepath.Join(c.home.Packs(), dir), noop, nil
+		}
+	}
+
+	ref, version := c.pack, ""
+	if i := strings.LastIndex(ref, "@"); i != -1 {
+		ref, version = ref[:i], ref[i+1:]
+	}
+	if !strings.Contains(ref, "/") {
+		return "", noop, fmt.Errorf("starter pack %q not found locally nor in %s", c.pack, c.home.Packs())
+	}
+
+	tmp, err := ioutil.TempDir("", "draft-packs-starter-")
+	if err != nil {
+		return "", noop, err
+	}
+	cleanup := func() { os.RemoveAll(tmp) }
+
+	dl := downloader.Downloader{
+		Home:    c.home,
+		Out:     os.Stdout,
+		Getters: AllProviders(),
+	}
+
+	filename, _, err := dl.DownloadTo(ref, version, tmp)
+	if err != nil {
+		cleanup()
+		return "", noop, fmt.Errorf("could not download starter pack %q: %s", c.pack, err)
+	}
+	return filename, cleanup, nil
+}
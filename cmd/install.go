@@ -18,33 +18,93 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/Azure/draft/pkg/draft/pack"
+	"github.com/gosuri/uitable"
 	"github.com/spf13/cobra"
-	"k8s.io/helm/pkg/getter"
+	"k8s.io/helm/pkg/provenance"
 
 	"github.com/rodcloutier/draft-packs/pkg/downloader"
 	"github.com/rodcloutier/draft-packs/pkg/draftpath"
 	. "github.com/rodcloutier/draft-packs/pkg/getter"
+	"github.com/rodcloutier/draft-packs/pkg/gitutil"
+	"github.com/rodcloutier/draft-packs/pkg/installer"
+	"github.com/rodcloutier/draft-packs/pkg/oci"
+	"github.com/rodcloutier/draft-packs/pkg/packutil"
 	"github.com/rodcloutier/draft-packs/pkg/repo"
+	"github.com/rodcloutier/draft-packs/pkg/resolver"
 )
 
-var installDesc = ""
+const installDesc = `
+This command installs packs in $DRAFT_HOME/packs.
+
+A pack can be a REPO/NAME[@VERSION] repository reference, a pack directory, a
+pack archive (.tgz) or an oci://REGISTRY/NAME[:TAG|@DIGEST] reference. Several
+packs can be installed at once: they are all located and downloaded, in
+parallel, before being installed.
+
+With --atomic, nothing is installed unless every pack can be installed.
+
+The packs required by the packs to install, declared in the dependencies of
+their metadata, are resolved against the cached repository indexes and
+installed first.
+
+With --from-lock, the packs of the lock file written by 'draft packs lock',
+draft-packs.lock or the one given with --lock-file, are installed instead. The
+installation fails if a downloaded archive does not match its locked digest.
+Packs already installed with the locked digest are left untouched.
+`
 
 type installCmd struct {
 	home     draftpath.Home
 	version  string
 	repoURL  string
-	name     string
+	names    []string
 	verify   bool
 	keyring  string
 	certFile string
 	keyFile  string
 	caFile   string
-	packPath string
+	priority int
+	force    bool
+	as       string
+	atomic   bool
+	parallel int
+	fromLock bool
+	lockFile string
+}
+
+// Status of the installation of a pack
+const (
+	statusInstalled        = "installed"
+	statusAlreadyInstalled = "already installed"
+	statusNotInstalled     = "not installed"
+	statusRolledBack       = "rolled back"
+)
+
+// packInstall is the installation of one of the packs given to install.
+type packInstall struct {
+	ref          string
+	version      string
+	as           string
+	priority     int
+	locked       *repo.LockedPack
+	local        bool
+	packPath     string
+	record       *repo.InstalledPack
+	pack         *pack.Pack
+	dependencies []*packutil.Dependency
+	name         string
+	existing     string
+	staged       *installer.Staged
+	status       string
+	err          error
 }
 
 func init() {
@@ -53,22 +113,29 @@ func init() {
 	}
 
 	cmd := &cobra.Command{
-		Use:   "install [PACK] [flags]",
-		Short: "install a pack for usage",
+		Use:   "install [PACK...] [flags]",
+		Short: "install packs for usage",
 		Long:  installDesc,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if len(args) != 1 {
+			if ic.fromLock {
+				if len(args) > 0 || ic.version != "" || ic.as != "" || ic.repoURL != "" || ic.priority != 0 {
+					return errors.New("PACK names, --version, --as, --repo and --priority cannot be used with --from-lock")
+				}
+			} else if len(args) == 0 {
 				return errors.New("Missing expected argument PACK name")
 			}
-			ic.name = args[0]
-
-			ic.home = draftpath.NewHome(homePath())
-			packPath, err := locatePackPath(ic.home, ic.repoURL, ic.name, ic.version, ic.verify, ic.keyring, ic.certFile, ic.keyFile, ic.caFile)
-			if err != nil {
-				return err
+			if len(args) > 1 && (ic.version != "" || ic.as != "") {
+				return errors.New("--version and --as can only be used to install a single pack, use REPO/NAME@VERSION to select versions")
+			}
+			if ic.priority < 0 || ic.priority > repo.MaxPriority {
+				return fmt.Errorf("priority must be between 0 and %d", repo.MaxPriority)
 			}
-			ic.packPath = packPath
+			if ic.parallel < 1 {
+				return errors.New("parallel must be at least 1")
+			}
+			ic.names = args
 
+			ic.home = draftpath.NewHome(homePath())
 			return ic.run()
 		},
 	}
@@ -81,60 +148,489 @@ func init() {
 	f.StringVar(&ic.certFile, "cert-file", "", "identify HTTPS client using this SSL certificate file")
 	f.StringVar(&ic.keyFile, "key-file", "", "identify HTTPS client using this SSL key file")
 	f.StringVar(&ic.caFile, "ca-file", "", "verify certificates of HTTPS-enabled servers using this CA bundle")
+	f.IntVar(&ic.priority, "priority", 0, "priority of the pack during detection, packs with a lower value are detected first. 0 means no priority")
+	f.BoolVar(&ic.force, "force", false, "reinstall the pack if a pack with the same name is already installed")
+	f.StringVar(&ic.as, "as", "", "name under which the pack is installed")
+	f.BoolVar(&ic.atomic, "atomic", false, "install nothing if any of the packs cannot be installed")
+	f.IntVar(&ic.parallel, "parallel", 4, "maximum number of packs downloaded at the same time")
+	f.BoolVar(&ic.fromLock, "from-lock", false, "install the packs of the lock file")
+	f.StringVar(&ic.lockFile, "lock-file", repo.LockFileName, "path to the lock file read with --from-lock")
 
 	RootCmd.AddCommand(cmd)
 }
 
 func (ic *installCmd) run() error {
-
-	packsDir := ic.home.Packs()
-
-	installed, err := isAlreadyInstalled(packsDir, ic.packPath)
+	var installs []*packInstall
+	var err error
+	if ic.fromLock {
+		installs, err = ic.parseLock()
+	} else {
+		installs, err = ic.parseArgs()
+	}
 	if err != nil {
 		return err
 	}
-	if installed {
-		fmt.Println("pack already installed")
+
+	ic.fetch(installs)
+	if ic.fromLock {
+		// The lock file already holds the resolved dependencies
+		ic.load(installs)
+	} else if installs, err = ic.resolveDependencies(installs); err != nil {
+		return err
+	}
+	ic.stage(installs)
+	if ic.atomic && failed(installs) {
+		for _, pi := range installs {
+			if pi.staged != nil {
+				pi.staged.Cleanup()
+				pi.staged = nil
+				pi.status = statusNotInstalled
+			}
+		}
+	}
+	ic.commit(installs)
+
+	if len(installs) == 1 {
+		pi := installs[0]
+		if pi.err != nil {
+			return pi.err
+		}
+		if pi.status == statusAlreadyInstalled {
+			fmt.Println("pack already installed")
+		} else {
+			fmt.Printf("Pack %s installed. Happy drafting!\n", pi.name)
+		}
 		return nil
 	}
 
-	p, err := pack.Load(ic.packPath)
+	table := uitable.New()
+	table.AddRow("PACK", "NAME", "VERSION", "STATUS")
+	for _, pi := range installs {
+		version := ""
+		if pi.pack != nil && pi.pack.Metadata != nil {
+			version = pi.pack.Metadata.Version
+		}
+		status := pi.status
+		if pi.err != nil {
+			status = fmt.Sprintf("failed: %s", pi.err)
+		}
+		ref := pi.ref
+		if pi.locked != nil {
+			ref = pi.locked.Name
+		}
+		table.AddRow(ref, pi.name, version, status)
+	}
+	fmt.Println(table)
+
+	if failed(installs) {
+		return errors.New("some packs could not be installed")
+	}
+	fmt.Println("Happy drafting!")
+	return nil
+}
+
+// parseArgs returns the installations requested by the arguments, given as
+// references optionally followed by @VERSION, or paths.
+func (ic *installCmd) parseArgs() ([]*packInstall, error) {
+	installs := []*packInstall{}
+	seen := map[string]bool{}
+	for _, arg := range ic.names {
+		pi := &packInstall{
+			ref:      strings.TrimSpace(arg),
+			version:  ic.version,
+			as:       ic.as,
+			priority: ic.priority,
+		}
+		if _, err := os.Stat(pi.ref); err == nil {
+			pi.local = true
+		} else if i := strings.LastIndex(pi.ref, "@"); i != -1 && !strings.HasPrefix(pi.ref, oci.Scheme+"://") {
+			// A REPO/NAME@VERSION reference, OCI references keep their @DIGEST
+			pi.ref, pi.version = pi.ref[:i], pi.ref[i+1:]
+		}
+		if seen[pi.ref] {
+			return nil, fmt.Errorf("pack %s is given more than once", pi.ref)
+		}
+		seen[pi.ref] = true
+		installs = append(installs, pi)
+	}
+	return installs, nil
+}
+
+// parseLock returns the installations of the packs of the lock file.
+func (ic *installCmd) parseLock() ([]*packInstall, error) {
+	lf, err := repo.LoadLockFile(ic.lockFile)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("unable to load lock file %s: %s", ic.lockFile, err)
 	}
 
-	// Names will be converted to repo-pack to respect the
-	// single level of directories in the pack directory
-	// TODO (rod) since the packs are reade in an alphabebitcal order
-	// maybe we would like to have a mechanism to order the installed
-	// packs or any pack.
-	name := strings.Replace(ic.name, "/", "-", -1)
+	installs := []*packInstall{}
+	for _, lp := range lf.Packs {
+		installs = append(installs, &packInstall{
+			ref:      lp.URL,
+			version:  lp.Version,
+			as:       lp.InstallName(),
+			priority: lp.Priority,
+			locked:   lp,
+		})
+	}
+	return installs, nil
+}
 
-	// Does it exists?
-	exists, err := packExists(name, packsDir)
+// fetch locates the packs to install, downloading them in parallel.
+func (ic *installCmd) fetch(installs []*packInstall) {
+	work := make(chan *packInstall)
+	var wg sync.WaitGroup
+	for i := 0; i < ic.parallel && i < len(installs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pi := range work {
+				if pi.packPath != "" {
+					// Already fetched
+					continue
+				}
+				pi.packPath, pi.record, pi.err = locatePackPath(ic.home, ic.repoURL, pi.ref, pi.version, ic.verify, ic.keyring, ic.certFile, ic.keyFile, ic.caFile)
+				if pi.err == nil && pi.locked != nil {
+					pi.err = checkLocked(pi)
+				}
+			}
+		}()
+	}
+	for _, pi := range installs {
+		work <- pi
+	}
+	close(work)
+	wg.Wait()
+}
+
+// resolveDependencies resolves the dependencies of the fetched packs, and
+// returns the installations of the required packs, fetched, followed by
+// installs.
+//
+// Dependencies on packs installed from a repository, or being installed, are
+// satisfied by the installed version.
+func (ic *installCmd) resolveDependencies(installs []*packInstall) ([]*packInstall, error) {
+	installed := map[string]string{}
+	records, err := repo.LoadInstalledPacks(ic.home.Installed())
 	if err != nil {
-		return err
+		return nil, err
+	}
+	for _, r := range records {
+		if r.Repository != "" {
+			installed[r.Reference] = r.Version
+		}
 	}
 
-	if exists {
-		return fmt.Errorf("pack with same name already exists")
+	ic.load(installs)
+	requirers := map[string][]*packutil.Dependency{}
+	for _, pi := range installs {
+		if pi.err != nil {
+			continue
+		}
+
+		ref := pi.reference()
+		if pi.record.Repository != "" && pi.pack.Metadata != nil {
+			installed[ref] = pi.pack.Metadata.Version
+		}
+		if len(pi.dependencies) > 0 {
+			requirers[ref] = pi.dependencies
+		}
+	}
+	if len(requirers) == 0 {
+		return installs, nil
 	}
 
-	packPath := filepath.Join(ic.home.Packs(), name)
-	if _, err := os.Stat(packPath); os.IsNotExist(err) {
-		os.MkdirAll(packPath, 0744)
+	// Packs are downloaded to read their dependencies, keep them for the
+	// installation.
+	fetched := map[string]*packInstall{}
+	r := &resolver.Resolver{
+		Index: func(name string) (*repo.IndexFile, error) {
+			return repo.LoadIndexFile(ic.home.CacheIndex(name))
+		},
+		Dependencies: func(ref string, v *repo.PackVersion) ([]*packutil.Dependency, error) {
+			pi := &packInstall{ref: ref, version: v.Version}
+			pi.packPath, pi.record, pi.err = locatePackPath(ic.home, "", ref, v.Version, ic.verify, ic.keyring, ic.certFile, ic.keyFile, ic.caFile)
+			if pi.err != nil {
+				return nil, pi.err
+			}
+			fetched[ref+"@"+v.Version] = pi
+			return packutil.LoadDependencies(pi.packPath)
+		},
+		Installed: installed,
+	}
+	resolved, err := r.Resolve(requirers)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve dependencies: %s", err)
+	}
+
+	deps := []*packInstall{}
+	for _, res := range resolved {
+		pi := fetched[res.Name+"@"+res.Version.Version]
+		if pi.pack, pi.err = pack.Load(pi.packPath); pi.err == nil {
+			pi.dependencies, pi.err = packutil.LoadDependencies(pi.packPath)
+		}
+		deps = append(deps, pi)
+	}
+	return append(deps, installs...), nil
+}
+
+// load loads the fetched packs and their dependencies.
+func (ic *installCmd) load(installs []*packInstall) {
+	for _, pi := range installs {
+		if pi.err != nil {
+			continue
+		}
+		if pi.pack, pi.err = pack.Load(pi.packPath); pi.err != nil {
+			continue
+		}
+		pi.dependencies, pi.err = packutil.LoadDependencies(pi.packPath)
 	}
+}
 
-	err = p.SaveDir(packPath, true)
+// installDependencies resolves the dependencies of the pack ref of the
+// repository repoName, fetched to packPath, and installs the packs it
+// requires that are not installed yet, as install does. It returns the
+// dependencies of the pack.
+func installDependencies(home draftpath.Home, repoName, ref, packPath string, verify bool, keyring string) ([]*packutil.Dependency, error) {
+	ic := &installCmd{
+		home:     home,
+		verify:   verify,
+		keyring:  keyring,
+		atomic:   true,
+		parallel: 1,
+	}
+	pi := &packInstall{
+		ref:      ref,
+		packPath: packPath,
+		record:   &repo.InstalledPack{Reference: ref, Repository: repoName},
+	}
+	installs, err := ic.resolveDependencies([]*packInstall{pi})
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if pi.err != nil {
+		return nil, pi.err
 	}
 
-	fmt.Printf("Pack %s installed. Happy drafting!\n", ic.name)
+	deps := installs[:len(installs)-1]
+	ic.stage(deps)
+	if failed(deps) {
+		for _, d := range deps {
+			if d.staged != nil {
+				d.staged.Cleanup()
+				d.staged = nil
+				d.status = statusNotInstalled
+			}
+		}
+	}
+	ic.commit(deps)
+	for _, d := range deps {
+		if d.err != nil {
+			return nil, fmt.Errorf("unable to install dependency %s: %s", d.ref, d.err)
+		}
+		if d.status == statusInstalled {
+			fmt.Printf("...Pack %s %s installed as a dependency of %s\n", d.ref, d.version, ref)
+		}
+	}
+	return pi.dependencies, nil
+}
 
+// checkLocked checks that the archive fetched for a locked pack matches its
+// locked digest, and records where the pack comes from.
+func checkLocked(pi *packInstall) error {
+	digest, err := provenance.DigestFile(pi.packPath)
+	if err != nil {
+		return err
+	}
+	if digest != pi.locked.Digest {
+		return fmt.Errorf("digest of %s is %s, expected %s", pi.locked.URL, digest, pi.locked.Digest)
+	}
+	pi.record.Reference = pi.locked.Name
+	pi.record.Repository = pi.locked.Repository()
+	pi.record.URL = pi.locked.URL
 	return nil
 }
 
+// stage loads the fetched packs and stages their installation.
+func (ic *installCmd) stage(installs []*packInstall) {
+	packsDir := ic.home.Packs()
+	names := map[string]string{}
+	for _, pi := range installs {
+		if pi.err != nil {
+			continue
+		}
+
+		installed, err := isAlreadyInstalled(packsDir, pi.packPath)
+		if err != nil {
+			pi.err = err
+			continue
+		}
+		if installed {
+			pi.status = statusAlreadyInstalled
+			continue
+		}
+
+		if pi.pack == nil {
+			if pi.pack, pi.err = pack.Load(pi.packPath); pi.err != nil {
+				continue
+			}
+		}
+		if pi.name, pi.err = ic.packName(pi); pi.err != nil {
+			continue
+		}
+		if ref, ok := names[pi.name]; ok {
+			pi.err = fmt.Errorf("%s is also installed as %s, use --as to install it under another name", ref, pi.name)
+			continue
+		}
+		names[pi.name] = pi.ref
+
+		// Since the packs are read in alphabetical order, the priority
+		// prefixes the directory of the pack
+		dir := repo.PackDirName(pi.name, pi.priority)
+
+		// Does it exists, whatever its priority?
+		existing, err := repo.FindPackDir(packsDir, pi.name)
+		if err == nil {
+			if pi.locked != nil && existing == dir {
+				if record, err := repo.LoadInstalledPack(ic.home.InstalledPack(pi.name)); err == nil && record.Digest == pi.locked.Digest {
+					pi.status = statusAlreadyInstalled
+					continue
+				}
+			}
+			if !ic.force {
+				pi.err = fmt.Errorf("pack with same name already exists, use --force to reinstall it or --as to install it under another name")
+				continue
+			}
+			if pi.priority == 0 && pi.locked == nil {
+				// Keep the priority of the installed pack
+				dir = existing
+			}
+			pi.existing = existing
+		} else if err != repo.ErrPackNotInstalled {
+			pi.err = err
+			continue
+		}
+
+		pi.staged, pi.err = installer.Stage(ic.home, dir, pi.pack)
+	}
+}
+
+// commit moves the staged packs into place and records their installation.
+//
+// A pack is not installed when one of its dependencies failed. With
+// --atomic, the packs already moved into place are rolled back when a pack
+// fails.
+func (ic *installCmd) commit(installs []*packInstall) {
+	// broken holds the references of the packs that failed
+	broken := map[string]bool{}
+	committed := []*packInstall{}
+	for _, pi := range installs {
+		for _, d := range pi.dependencies {
+			if pi.err == nil && broken[d.Name] {
+				pi.err = fmt.Errorf("dependency %s was not installed", d.Name)
+			}
+		}
+		if pi.err == nil && pi.staged != nil {
+			if pi.err = pi.staged.Commit(); pi.err == nil {
+				committed = append(committed, pi)
+			}
+		}
+		if pi.err != nil {
+			broken[pi.reference()] = true
+			if ic.atomic {
+				break
+			}
+		}
+	}
+
+	if ic.atomic && failed(installs) {
+		for i := len(committed) - 1; i >= 0; i-- {
+			pi := committed[i]
+			if err := pi.staged.Rollback(); err != nil {
+				pi.err = fmt.Errorf("could not be rolled back: %s", err)
+				continue
+			}
+			pi.status = statusRolledBack
+		}
+		committed = nil
+	}
+
+	for _, pi := range installs {
+		if pi.staged != nil {
+			pi.staged.Cleanup()
+			if pi.err == nil && pi.status == "" {
+				pi.status = statusNotInstalled
+			}
+		}
+	}
+
+	for _, pi := range committed {
+		pi.status = statusInstalled
+		if pi.existing != "" && pi.existing != filepath.Base(pi.staged.Dest) {
+			if err := os.RemoveAll(filepath.Join(ic.home.Packs(), pi.existing)); err != nil {
+				pi.err = err
+				continue
+			}
+		}
+		pi.record.Dependencies = nil
+		for _, d := range pi.dependencies {
+			pi.record.Dependencies = append(pi.record.Dependencies, d.Name)
+		}
+		pi.err = writeInstallRecord(ic.home, pi.name, pi.record, pi.packPath, pi.pack)
+	}
+}
+
+// reference returns the reference other packs use to depend on the pack.
+func (pi *packInstall) reference() string {
+	if pi.record != nil && pi.record.Repository != "" {
+		return pi.record.Reference
+	}
+	return pi.ref
+}
+
+// failed reports whether the installation of any of the packs failed.
+func failed(installs []*packInstall) bool {
+	for _, pi := range installs {
+		if pi.err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// packName returns the name under which the pack of pi is installed.
+//
+// Unless a name is given with --as, packs installed from a local directory
+// or archive are named after their metadata, or their chart when they have
+// no metadata. Other names will be converted to repo-pack to respect the
+// single level of directories in the pack directory.
+func (ic *installCmd) packName(pi *packInstall) (string, error) {
+	p := pi.pack
+	name := pi.as
+	if name == "" && !pi.local {
+		if ref := pi.reference(); !strings.HasPrefix(ref, oci.Scheme+"://") {
+			return strings.Replace(ref, "/", "-", -1), nil
+		}
+		// The packs of a registry that is not a repository are named after
+		// their metadata, as local packs
+	}
+
+	if name == "" {
+		if p.Metadata != nil && p.Metadata.Name != "" {
+			name = p.Metadata.Name
+		} else if p.Chart != nil && p.Chart.Metadata != nil {
+			name = p.Chart.Metadata.Name
+		}
+	}
+	if name == "" {
+		return "", fmt.Errorf("pack %s has no name", pi.ref)
+	}
+	if strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return "", fmt.Errorf("pack %s has an invalid name %q", pi.ref, name)
+	}
+	return name, nil
+}
+
 func isAlreadyInstalled(packs, pack string) (bool, error) {
 
 	packsDir, err := os.Stat(packs)
@@ -175,47 +671,83 @@ func packExists(name, packs string) (bool, error) {
 	return false, nil
 }
 
-// defaultKeyring returns the expanded path to the default keyring.
+// findInstalled finds the pack installed as name, or installed from the
+// reference name, and returns its installed name and its directory.
+func findInstalled(home draftpath.Home, name string) (string, string, error) {
+	installName := strings.Replace(name, "/", "-", -1)
+	if dir, err := repo.FindPackDir(home.Packs(), installName); err != repo.ErrPackNotInstalled {
+		return installName, dir, err
+	}
+
+	records, err := repo.LoadInstalledPacks(home.Installed())
+	if err != nil {
+		return "", "", err
+	}
+	found := []string{}
+	for _, r := range records {
+		if r.Reference == name {
+			found = append(found, r.Name)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", "", repo.ErrPackNotInstalled
+	case 1:
+		dir, err := repo.FindPackDir(home.Packs(), found[0])
+		return found[0], dir, err
+	}
+	return "", "", fmt.Errorf("%s is installed as %s, specify the installed name", name, strings.Join(found, ", "))
+}
+
+// defaultKeyring returns the path to the keyring managed by 'draft packs keys'.
 func defaultKeyring() string {
-	return os.ExpandEnv("$HOME/.gnupg/pubring.gpg")
+	return draftpath.NewHome(homePath()).Keyring()
 }
 
+// locatePackPath finds the pack to install and returns its path along with
+// the install record describing where it comes from.
 func locatePackPath(home draftpath.Home, repoURL, name, version string, verify bool, keyring,
-	certFile, keyFile, caFile string) (string, error) {
+	certFile, keyFile, caFile string) (string, *repo.InstalledPack, error) {
 
 	name = strings.TrimSpace(name)
 	version = strings.TrimSpace(version)
 
+	record := repo.NewInstalledPack("")
+	record.Reference = name
+
 	if fi, err := os.Stat(name); err == nil {
 		abs, err := filepath.Abs(name)
 		if err != nil {
-			return abs, err
+			return abs, nil, err
+		}
+		if !fi.IsDir() && strings.ToLower(filepath.Ext(abs)) != ".tgz" {
+			return "", nil, fmt.Errorf("%s is neither a pack directory nor a pack archive (.tgz)", name)
 		}
 		if verify {
 			if fi.IsDir() {
-				return "", errors.New("cannot verify a directory")
+				return "", nil, errors.New("cannot verify a directory")
 			}
-			if _, err := downloader.VerifyFile(abs, keyring); err != nil {
-				return "", err
+			ver, err := downloader.VerifyFile(abs, keyring)
+			if err != nil {
+				return "", nil, err
 			}
+			setVerification(record, ver)
 		}
+		// A relative path is meaningless once installed
+		record.Reference = abs
+		return abs, record, nil
 	}
 	if filepath.IsAbs(name) || strings.HasPrefix(name, ".") {
-		return name, fmt.Errorf("path %q not found", name)
+		return name, nil, fmt.Errorf("path %q not found", name)
 	}
 
 	// find the pack directory
-	packRepo := filepath.Join(home.Packs(), name)
-	if _, err := os.Stat(packRepo); err == nil {
-		return filepath.Abs(packRepo)
+	if dir, err := repo.FindPackDir(home.Packs(), name); err == nil {
+		abs, err := filepath.Abs(filepath.Join(home.Packs(), dir))
+		return abs, record, err
 	}
 
-	providers := getter.Providers{
-		{
-			Schemes: []string{"http", "https"},
-			New:     NewHTTPGetter,
-		},
-	}
+	providers := AllProviders()
 
 	dl := downloader.Downloader{
 		Home:    home,
@@ -231,24 +763,100 @@ func locatePackPath(home draftpath.Home, repoURL, name, version string, verify b
 		lname, err := repo.FindPackInRepoURL(repoURL, name, version,
 			certFile, keyFile, caFile, providers)
 		if err != nil {
-			return "", err
+			return "", nil, err
 		}
 		name = lname
+	} else if u, err := url.Parse(name); err == nil && !u.IsAbs() && strings.Contains(name, "/") {
+		// A repo/name reference
+		record.Repository = strings.SplitN(name, "/", 2)[0]
+	} else if repoName, packName := registryRepository(home, name); repoName != "" {
+		// A pack of a registry added as a repository
+		record.Repository = repoName
+		record.Reference = repoName + "/" + packName
+	}
+
+	if u, _, err := dl.ResolveVersion(name, version); err == nil {
+		record.URL = u.String()
 	}
 
 	if _, err := os.Stat(home.Archive()); os.IsNotExist(err) {
 		os.MkdirAll(home.Archive(), 0744)
 	}
 
-	filename, _, err := dl.DownloadTo(name, version, home.Archive())
+	filename, ver, err := dl.DownloadTo(name, version, home.Archive())
 	if err == nil {
 		lname, err := filepath.Abs(filename)
 		if err != nil {
-			return filename, err
+			return filename, nil, err
 		}
+		// The repository may require the verification, even without --verify
+		setVerification(record, ver)
 		// debug("Fetched %s to %s\n", name, filename)
-		return lname, nil
+		return lname, record, nil
+	}
+
+	return filename, nil, fmt.Errorf("file %q not found", name)
+}
+
+// registryRepository returns the name of the repository holding the pack of
+// the OCI reference ref, and the name of the pack in the repository. The names
+// are empty unless the pack is directly under the namespace of a repository.
+func registryRepository(home draftpath.Home, ref string) (string, string) {
+	if !strings.HasPrefix(ref, oci.Scheme+"://") {
+		return "", ""
+	}
+	r, err := oci.ParseReference(ref)
+	if err != nil {
+		return "", ""
+	}
+	rf, err := repo.LoadRepositoriesFile(home.RepositoryFile())
+	if err != nil {
+		return "", ""
 	}
+	base := (&oci.Reference{Registry: r.Registry, Repository: r.Repository}).String()
+	for _, e := range rf.Repositories {
+		if strings.TrimSuffix(e.URL, "/")+"/"+r.Name() == base {
+			return e.Name, r.Name()
+		}
+	}
+	return "", ""
+}
 
-	return filename, fmt.Errorf("file %q not found", name)
+// setVerification records the result of the verification of a pack.
+func setVerification(record *repo.InstalledPack, ver *provenance.Verification) {
+	if ver == nil || ver.FileHash == "" {
+		return
+	}
+	record.Verified = true
+	if ver.SignedBy != nil {
+		for identity := range ver.SignedBy.Identities {
+			record.SignedBy = identity
+			break
+		}
+	}
+}
+
+// writeInstallRecord completes the install record of the pack p, installed
+// as name from packPath, and saves it in $DRAFT_HOME.
+func writeInstallRecord(home draftpath.Home, name string, record *repo.InstalledPack, packPath string, p *pack.Pack) error {
+	if record == nil {
+		record = repo.NewInstalledPack(name)
+	}
+	record.Name = name
+	record.Installed = time.Now()
+	if p.Metadata != nil {
+		record.Version = p.Metadata.Version
+	}
+	if fi, err := os.Stat(packPath); err == nil && !fi.IsDir() {
+		digest, err := provenance.DigestFile(packPath)
+		if err != nil {
+			return err
+		}
+		record.Digest = digest
+	}
+	record.Commit = ""
+	if _, commit, _, err := gitutil.ParsePackURL(record.URL); err == nil {
+		record.Commit = commit
+	}
+	return record.WriteFile(home.InstalledPack(name), 0644)
 }
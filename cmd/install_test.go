@@ -0,0 +1,119 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rodcloutier/draft-packs/pkg/draftpath"
+	"github.com/rodcloutier/draft-packs/pkg/oci"
+	"github.com/rodcloutier/draft-packs/pkg/oci/ocitest"
+	"github.com/rodcloutier/draft-packs/pkg/packutil"
+	"github.com/rodcloutier/draft-packs/pkg/repo"
+)
+
+func TestCommitFailedDependency(t *testing.T) {
+	home, err := ioutil.TempDir("", "draft-packs-home-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+
+	base := &packInstall{
+		ref:    "stable/base",
+		record: &repo.InstalledPack{Reference: "stable/base", Repository: "stable"},
+		err:    errors.New("download failed"),
+	}
+	grpc := &packInstall{
+		ref:          "stable/grpc",
+		record:       &repo.InstalledPack{Reference: "stable/grpc", Repository: "stable"},
+		dependencies: []*packutil.Dependency{{Name: "stable/base"}},
+	}
+	app := &packInstall{
+		ref:          "./app",
+		record:       &repo.InstalledPack{},
+		dependencies: []*packutil.Dependency{{Name: "stable/grpc"}},
+	}
+
+	ic := &installCmd{home: draftpath.NewHome(home)}
+	ic.commit([]*packInstall{base, grpc, app})
+
+	if grpc.err == nil || !strings.Contains(grpc.err.Error(), "stable/base") {
+		t.Errorf("Expected stable/grpc to fail on its dependency, got %v", grpc.err)
+	}
+	if app.err == nil || !strings.Contains(app.err.Error(), "stable/grpc") {
+		t.Errorf("Expected ./app to fail on its dependency, got %v", app.err)
+	}
+}
+
+func TestFetchOCIReference(t *testing.T) {
+	registry := ocitest.NewRegistry()
+	defer registry.Stop()
+
+	tmp, err := ioutil.TempDir("", "draft-packs-home-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	home := draftpath.NewHome(tmp)
+	for _, p := range []string{home.Cache(), home.Packs()} {
+		if err := os.MkdirAll(p, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repoURL := "oci://" + registry.Host() + "/packs"
+	rf := repo.NewRepoFile()
+	rf.Add(&repo.Entry{Name: "registry", URL: repoURL, Cache: home.CacheIndex("registry")})
+	if err := rf.WriteFile(home.RepositoryFile(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	digest, err := oci.NewClient(nil).Push(&oci.Reference{Registry: registry.Host(), Repository: "packs/golang", Tag: "1.0.0"}, []byte("{}"), []byte("archive"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ref := range []string{repoURL + "/golang:1.0.0", repoURL + "/golang@" + digest} {
+		ic := &installCmd{home: home, names: []string{ref}, parallel: 1}
+		installs, err := ic.parseArgs()
+		if err != nil {
+			t.Fatal(err)
+		}
+		pi := installs[0]
+		if pi.ref != ref || pi.version != "" {
+			t.Errorf("%s: expected the reference to be kept whole, got %s and version %q", ref, pi.ref, pi.version)
+		}
+
+		ic.fetch(installs)
+		if pi.err != nil {
+			t.Errorf("%s: %s", ref, pi.err)
+			continue
+		}
+		if pi.record.Repository != "registry" || pi.record.Reference != "registry/golang" {
+			t.Errorf("%s: expected to be installed from registry/golang, got %s from %q", ref, pi.record.Reference, pi.record.Repository)
+		}
+		if expect := repoURL + "/golang"; !strings.HasPrefix(pi.record.URL, expect) || !strings.HasSuffix(pi.record.URL, "@"+digest) {
+			t.Errorf("%s: expected the URL to be pinned to %s, got %s", ref, digest, pi.record.URL)
+		}
+		if name, err := ic.packName(pi); err != nil || name != "registry-golang" {
+			t.Errorf("%s: expected to be installed as registry-golang, got %q (%v)", ref, name, err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/openpgp"
+
+	"github.com/rodcloutier/draft-packs/pkg/draftpath"
+	"github.com/rodcloutier/draft-packs/pkg/keyring"
+)
+
+type keysExportCmd struct {
+	home draftpath.Home
+	ids  []string
+}
+
+func init() {
+	export := &keysExportCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "export [flags] [KEY...]",
+		Short: "export keys of the keyring, ASCII armored, to stdout. All the keys are exported when none is given",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			export.ids = args
+			export.home = draftpath.NewHome(os.ExpandEnv("$DRAFT_HOME"))
+			return export.run()
+		},
+	}
+
+	RootCmd.AddCommand(cmd)
+}
+
+func (e *keysExportCmd) run() error {
+	k, err := keyring.LoadKeyring(e.home.Keyring())
+	if err != nil {
+		return err
+	}
+
+	entities := k.Entities
+	if len(e.ids) > 0 {
+		entities = openpgp.EntityList{}
+		for _, id := range e.ids {
+			found := k.Find(id)
+			if len(found) == 0 {
+				return fmt.Errorf("%s: %q", keyring.ErrKeyNotFound, id)
+			}
+			entities = append(entities, found...)
+		}
+	}
+	if len(entities) == 0 {
+		return keyring.ErrKeyNotFound
+	}
+
+	if err := keyring.Export(os.Stdout, entities); err != nil {
+		return err
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rodcloutier/draft-packs/pkg/draftpath"
+	"github.com/rodcloutier/draft-packs/pkg/keyring"
+)
+
+type keysImportCmd struct {
+	home  draftpath.Home
+	files []string
+}
+
+func init() {
+	imp := &keysImportCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "import [flags] FILE...",
+		Short: "import public keys, armored or binary, in the keyring. Use - to read from stdin",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return errors.New("missing expected argument FILE")
+			}
+			imp.files = args
+			imp.home = draftpath.NewHome(os.ExpandEnv("$DRAFT_HOME"))
+			return imp.run()
+		},
+	}
+
+	RootCmd.AddCommand(cmd)
+}
+
+func (i *keysImportCmd) run() error {
+	k, err := keyring.LoadKeyring(i.home.Keyring())
+	if err != nil {
+		return err
+	}
+
+	for _, file := range i.files {
+		var data []byte
+		if file == "-" {
+			data, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			data, err = ioutil.ReadFile(file)
+		}
+		if err != nil {
+			return err
+		}
+
+		entities, err := keyring.ReadKeys(data)
+		if err != nil {
+			return fmt.Errorf("could not import %s: %s", file, err)
+		}
+		k.Import(entities)
+		for _, e := range entities {
+			fmt.Printf("Imported key %s", keyring.Fingerprint(e))
+			if ids := keyring.Identities(e); len(ids) > 0 {
+				fmt.Printf(" %s", ids[0])
+			}
+			fmt.Println()
+		}
+	}
+
+	return k.WriteFile(i.home.Keyring(), 0644)
+}
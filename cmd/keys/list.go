@@ -0,0 +1,65 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+
+	"github.com/rodcloutier/draft-packs/pkg/draftpath"
+	"github.com/rodcloutier/draft-packs/pkg/keyring"
+)
+
+type keysListCmd struct {
+	home draftpath.Home
+}
+
+func init() {
+	list := &keysListCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "list [flags]",
+		Short: "list the keys of the keyring",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			list.home = draftpath.NewHome(os.ExpandEnv("$DRAFT_HOME"))
+			return list.run()
+		},
+	}
+
+	RootCmd.AddCommand(cmd)
+}
+
+func (l *keysListCmd) run() error {
+	k, err := keyring.LoadKeyring(l.home.Keyring())
+	if err != nil {
+		return err
+	}
+	if len(k.Entities) == 0 {
+		fmt.Println("No keys in keyring. Use 'draft packs keys import' to add keys.")
+		return nil
+	}
+
+	table := uitable.New()
+	table.AddRow("FINGERPRINT", "IDENTITY")
+	for _, e := range k.Entities {
+		table.AddRow(keyring.Fingerprint(e), strings.Join(keyring.Identities(e), ", "))
+	}
+	fmt.Println(table)
+	return nil
+}
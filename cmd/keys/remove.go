@@ -0,0 +1,71 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rodcloutier/draft-packs/pkg/draftpath"
+	"github.com/rodcloutier/draft-packs/pkg/keyring"
+)
+
+type keysRemoveCmd struct {
+	home draftpath.Home
+	ids  []string
+}
+
+func init() {
+	remove := &keysRemoveCmd{}
+
+	cmd := &cobra.Command{
+		Use:     "remove [flags] KEY...",
+		Aliases: []string{"rm"},
+		Short:   "remove keys, given by fingerprint, key ID or identity, from the keyring",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return errors.New("missing expected argument KEY")
+			}
+			remove.ids = args
+			remove.home = draftpath.NewHome(os.ExpandEnv("$DRAFT_HOME"))
+			return remove.run()
+		},
+	}
+
+	RootCmd.AddCommand(cmd)
+}
+
+func (r *keysRemoveCmd) run() error {
+	k, err := keyring.LoadKeyring(r.home.Keyring())
+	if err != nil {
+		return err
+	}
+
+	for _, id := range r.ids {
+		if found := k.Find(id); len(found) > 1 {
+			return fmt.Errorf("%q matches %d keys, use a fingerprint to select one", id, len(found))
+		}
+		removed, err := k.Remove(id)
+		if err != nil {
+			return fmt.Errorf("%s: %q", err, id)
+		}
+		fmt.Printf("Removed key %s\n", keyring.Fingerprint(removed[0]))
+	}
+
+	return k.WriteFile(r.home.Keyring(), 0644)
+}
@@ -0,0 +1,35 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keys
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var keysDraft = `
+This command consists of multiple subcommands to manage the keyring used to
+verify the provenance of packs.
+
+The keyring is stored in $DRAFT_HOME/repository/keyring.gpg and is used by
+default by 'draft packs install --verify' and 'draft packs verify'.
+Example usage:
+    $ draft packs keys import publisher.asc
+`
+
+var RootCmd = &cobra.Command{
+	Use:   "keys [FLAGS] import|list|remove|export [ARGS]",
+	Short: "import, list, remove and export the keys used to verify packs",
+	Long:  keysDraft,
+}
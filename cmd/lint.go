@@ -0,0 +1,88 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rodcloutier/draft-packs/pkg/lint"
+)
+
+const packLintDesc = `
+This command takes a path to a pack, or to a packaged pack, and runs a series
+of tests to verify that the pack is well-formed.
+
+If the linter encounters things that will prevent Draft from using the pack,
+it will emit [ERROR] messages and exit with a non-zero status. If it
+encounters issues that break with convention, it will emit [WARNING] messages.
+`
+
+type packLintCmd struct {
+	strict bool
+	paths  []string
+}
+
+func init() {
+	lc := &packLintCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "lint [flags] PACK_PATH [...]",
+		Short: "examine a pack for possible issues",
+		Long:  packLintDesc,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("need at least one argument, the path to the pack")
+			}
+			lc.paths = args
+			return lc.run()
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVar(&lc.strict, "strict", false, "fail on lint warnings")
+
+	RootCmd.AddCommand(cmd)
+}
+
+func (lc *packLintCmd) run() error {
+	failures := 0
+	for _, path := range lc.paths {
+		fmt.Printf("==> Linting %s\n", path)
+
+		l, err := lint.Path(path)
+		if err != nil {
+			fmt.Printf("[ERROR] %s\n", err)
+			failures++
+			continue
+		}
+
+		for _, m := range l.Messages {
+			fmt.Println(m.Error())
+		}
+
+		if l.HighestSeverity == lint.ErrorSev || (lc.strict && l.HighestSeverity == lint.WarningSev) {
+			failures++
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("%d pack(s) linted, %d pack(s) failed\n", len(lc.paths), failures)
+	if failures > 0 {
+		return fmt.Errorf("%d pack(s) failed linting", failures)
+	}
+	return nil
+}
@@ -15,16 +15,43 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 
-	"github.com/rodcloutier/draft-packs/pkg/draftpath"
+	"github.com/Azure/draft/pkg/draft/pack"
+	"github.com/ghodss/yaml"
+	"github.com/gosuri/uitable"
 	"github.com/spf13/cobra"
+
+	"github.com/rodcloutier/draft-packs/pkg/draftpath"
+	"github.com/rodcloutier/draft-packs/pkg/repo"
+)
+
+const (
+	sourceBuiltin = "builtin"
+	sourceRepo    = "repo"
+	sourceLocal   = "local"
 )
 
 type packListCmd struct {
-	home draftpath.Home
+	home   draftpath.Home
+	output string
+}
+
+// packListEntry describes an installed pack.
+type packListEntry struct {
+	// Order is the position of the pack in the detection order of Draft.
+	Order       int    `json:"order"`
+	Name        string `json:"name"`
+	Priority    int    `json:"priority,omitempty"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+	Source      string `json:"source"`
+	Repository  string `json:"repository,omitempty"`
+	Verified    bool   `json:"verified"`
 }
 
 func init() {
@@ -34,12 +61,15 @@ func init() {
 
 	cmd := &cobra.Command{
 		Use:   "list [flags]",
-		Short: "list packs",
+		Short: "list packs, in the order they are used for detection",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return list.run()
 		},
 	}
 
+	f := cmd.Flags()
+	f.StringVarP(&list.output, "output", "o", "", "output format, one of: json, yaml. Defaults to a table")
+
 	RootCmd.AddCommand(cmd)
 }
 
@@ -51,11 +81,82 @@ func (p *packListCmd) run() error {
 		return fmt.Errorf("there was an error reading %s: %v", packHomeDir, err)
 	}
 
+	builtins, err := pack.Builtins()
+	if err != nil {
+		return err
+	}
+
+	entries := []*packListEntry{}
 	for _, file := range files {
-		if file.IsDir() {
-			fmt.Println(file.Name())
+		if !file.IsDir() {
+			continue
+		}
+		// Draft reads the packs in the order of their directories
+		name, priority := repo.ParsePackDirName(file.Name())
+		e := &packListEntry{
+			Order:    len(entries) + 1,
+			Name:     name,
+			Priority: priority,
+			Source:   sourceLocal,
+		}
+
+		if pck, err := pack.FromDir(filepath.Join(packHomeDir, file.Name())); err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: pack %s could not be loaded: %s\n", name, err)
+		} else if pck.Metadata != nil {
+			e.Version = pck.Metadata.Version
+			e.Description = pck.Metadata.Description
 		}
+
+		if record, err := repo.LoadInstalledPack(p.home.InstalledPack(name)); err == nil {
+			e.Verified = record.Verified
+			if record.Repository != "" {
+				e.Source = sourceRepo
+				e.Repository = record.Repository
+			}
+		} else if _, ok := builtins[name]; ok {
+			e.Source = sourceBuiltin
+		}
+
+		entries = append(entries, e)
 	}
 
+	out, err := p.format(entries)
+	if err != nil {
+		return err
+	}
+	fmt.Println(out)
 	return nil
 }
+
+func (p *packListCmd) format(entries []*packListEntry) (string, error) {
+	switch p.output {
+	case "json":
+		b, err := json.MarshalIndent(entries, "", "  ")
+		return string(b), err
+	case "yaml":
+		b, err := yaml.Marshal(entries)
+		return string(b), err
+	case "":
+	default:
+		return "", fmt.Errorf("unknown output format %q", p.output)
+	}
+
+	if len(entries) == 0 {
+		return "No packs installed", nil
+	}
+	table := uitable.New()
+	table.MaxColWidth = 50
+	table.AddRow("ORDER", "NAME", "PRIORITY", "VERSION", "DESCRIPTION", "SOURCE", "VERIFIED")
+	for _, e := range entries {
+		source := e.Source
+		if e.Repository != "" {
+			source = fmt.Sprintf("%s (%s)", e.Source, e.Repository)
+		}
+		priority := "-"
+		if e.Priority > 0 {
+			priority = fmt.Sprint(e.Priority)
+		}
+		table.AddRow(e.Order, e.Name, priority, e.Version, e.Description, source, e.Verified)
+	}
+	return table.String(), nil
+}
@@ -0,0 +1,179 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rodcloutier/draft-packs/pkg/downloader"
+	"github.com/rodcloutier/draft-packs/pkg/draftpath"
+	. "github.com/rodcloutier/draft-packs/pkg/getter"
+	"github.com/rodcloutier/draft-packs/pkg/packutil"
+	"github.com/rodcloutier/draft-packs/pkg/repo"
+	"github.com/rodcloutier/draft-packs/pkg/resolver"
+)
+
+const lockDesc = `
+This command resolves the packs declared by a pack manifest, and the packs they
+depend on, to exact versions, and writes their archive URL and digest to a lock
+file.
+
+The versions are read from the cached repository indexes, run
+'draft packs repo update' or 'draft packs sync' beforehand to get the latest
+information.
+
+The packs of the lock file are installed with 'draft packs install --from-lock',
+which fails if a downloaded archive does not match its locked digest.
+`
+
+type lockCmd struct {
+	home     draftpath.Home
+	file     string
+	lockFile string
+}
+
+func init() {
+	lc := &lockCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "lock [flags]",
+		Short: "resolve the packs of a manifest to exact versions in a lock file",
+		Long:  lockDesc,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return errors.New("lock does not accept arguments, use --file to select the manifest")
+			}
+			lc.home = draftpath.NewHome(homePath())
+			return lc.run()
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&lc.file, "file", "f", repo.ManifestFile, "path to the pack manifest")
+	f.StringVar(&lc.lockFile, "lock-file", repo.LockFileName, "path to the lock file to write")
+
+	RootCmd.AddCommand(cmd)
+}
+
+func (lc *lockCmd) run() error {
+	m, err := repo.LoadManifest(lc.file)
+	if err != nil {
+		return fmt.Errorf("unable to load manifest %s: %s", lc.file, err)
+	}
+
+	dl := downloader.Downloader{
+		Home:    lc.home,
+		Out:     os.Stdout,
+		Getters: AllProviders(),
+	}
+
+	// The archives are downloaded to read the dependencies of the packs
+	tmp, err := ioutil.TempDir("", "draft-packs-lock-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	dependencies := func(ref string, v *repo.PackVersion) ([]*packutil.Dependency, error) {
+		filename, _, err := dl.DownloadTo(ref, v.Version, tmp)
+		if err != nil {
+			return nil, err
+		}
+		return packutil.LoadDependencies(filename)
+	}
+
+	locked := map[string]string{}
+	requirers := map[string][]*packutil.Dependency{}
+	packs := []*repo.LockedPack{}
+	for _, mp := range m.Packs {
+		index, err := repo.LoadIndexFile(lc.home.CacheIndex(mp.Repository()))
+		if err != nil {
+			return fmt.Errorf("no cached repo found for pack %s. (try 'draft packs repo update'). %s", mp.Name, err)
+		}
+		index.SortEntries()
+		cv, err := index.Get(mp.PackName(), mp.Version)
+		if err != nil {
+			return fmt.Errorf("pack %s: %s", mp.Name, err)
+		}
+		if cv.Digest == "" {
+			return fmt.Errorf("pack %s %s has no digest in the index of repository %s", mp.Name, cv.Version, mp.Repository())
+		}
+		u, _, err := dl.ResolveVersion(mp.Name, cv.Version)
+		if err != nil {
+			return fmt.Errorf("pack %s: %s", mp.Name, err)
+		}
+
+		deps, err := dependencies(mp.Name, cv)
+		if err != nil {
+			return fmt.Errorf("pack %s: %s", mp.Name, err)
+		}
+		if len(deps) > 0 {
+			requirers[mp.Name] = deps
+		}
+		locked[mp.Name] = cv.Version
+
+		packs = append(packs, &repo.LockedPack{
+			Name:     mp.Name,
+			As:       mp.As,
+			Priority: mp.Priority,
+			Version:  cv.Version,
+			URL:      u.String(),
+			Digest:   cv.Digest,
+		})
+		fmt.Printf("...Locked %s to %s\n", mp.Name, cv.Version)
+	}
+
+	r := &resolver.Resolver{
+		Index: func(name string) (*repo.IndexFile, error) {
+			return repo.LoadIndexFile(lc.home.CacheIndex(name))
+		},
+		Dependencies: dependencies,
+		Installed:    locked,
+	}
+	resolved, err := r.Resolve(requirers)
+	if err != nil {
+		return fmt.Errorf("unable to resolve dependencies: %s", err)
+	}
+
+	// Dependencies come first, in the order they are installed
+	lf := repo.NewLockFile()
+	for _, res := range resolved {
+		if res.Version.Digest == "" {
+			return fmt.Errorf("pack %s %s has no digest in its repository index", res.Name, res.Version.Version)
+		}
+		u, _, err := dl.ResolveVersion(res.Name, res.Version.Version)
+		if err != nil {
+			return fmt.Errorf("pack %s: %s", res.Name, err)
+		}
+		lf.Packs = append(lf.Packs, &repo.LockedPack{
+			Name:    res.Name,
+			Version: res.Version.Version,
+			URL:     u.String(),
+			Digest:  res.Version.Digest,
+		})
+		fmt.Printf("...Locked dependency %s to %s\n", res.Name, res.Version.Version)
+	}
+	lf.Packs = append(lf.Packs, packs...)
+
+	if err := lf.WriteFile(lc.lockFile, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Lock file written to %s\n", lc.lockFile)
+	return nil
+}
@@ -0,0 +1,195 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure/draft/pkg/draft/pack"
+	"github.com/Masterminds/semver"
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+
+	"github.com/rodcloutier/draft-packs/pkg/draftpath"
+	"github.com/rodcloutier/draft-packs/pkg/repo"
+)
+
+const outdatedDesc = `
+This command lists the installed packs for which a newer version is available
+in the pack repositories.
+
+The versions available are read from the cached repository indexes, run
+'draft packs repo update' beforehand to get the latest information.
+
+Use --version to also report the latest version matching a semantic
+versioning constraint.
+
+The command exits with a non-zero status when at least one pack is outdated or
+could not be checked.
+`
+
+type outdatedCmd struct {
+	home    draftpath.Home
+	version string
+	output  string
+}
+
+// outdatedPack describes an installed pack for which a newer version exists.
+type outdatedPack struct {
+	Name       string `json:"name"`
+	Repository string `json:"repository"`
+	Installed  string `json:"installed"`
+	Latest     string `json:"latest"`
+	Wanted     string `json:"wanted,omitempty"`
+}
+
+func init() {
+	oc := &outdatedCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "outdated [flags]",
+		Short: "list installed packs for which a newer version is available",
+		Long:  outdatedDesc,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if oc.output != "" && oc.output != "json" {
+				return fmt.Errorf("unknown output format %q", oc.output)
+			}
+			if oc.version != "" {
+				if _, err := semver.NewConstraint(oc.version); err != nil {
+					return fmt.Errorf("invalid version constraint %q: %s", oc.version, err)
+				}
+			}
+			oc.home = draftpath.NewHome(homePath())
+			return oc.run()
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&oc.version, "version", "v", "", "semantic versioning constraint used to report the latest matching version")
+	f.StringVarP(&oc.output, "output", "o", "", "output format, one of: json. Defaults to a table")
+
+	RootCmd.AddCommand(cmd)
+}
+
+func (oc *outdatedCmd) run() error {
+	files, err := ioutil.ReadDir(oc.home.Packs())
+	if err != nil {
+		return err
+	}
+
+	rf, err := repo.LoadRepositoriesFile(oc.home.RepositoryFile())
+	if err != nil {
+		return err
+	}
+
+	outdated := []*outdatedPack{}
+	failed := 0
+	for _, f := range files {
+		if !f.IsDir() {
+			continue
+		}
+		o, err := oc.check(f.Name(), rf)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: unable to check pack %s: %s\n", f.Name(), err)
+			failed++
+			continue
+		}
+		if o != nil {
+			outdated = append(outdated, o)
+		}
+	}
+
+	if oc.output == "json" {
+		b, err := json.MarshalIndent(outdated, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+	} else if len(outdated) == 0 && failed == 0 {
+		fmt.Println("All packs are up to date")
+	} else if len(outdated) > 0 {
+		table := uitable.New()
+		table.AddRow("NAME", "REPOSITORY", "INSTALLED", "LATEST", "WANTED")
+		for _, o := range outdated {
+			table.AddRow(o.Name, o.Repository, o.Installed, o.Latest, o.Wanted)
+		}
+		fmt.Println(table)
+	}
+
+	switch {
+	case failed > 0:
+		return fmt.Errorf("%d pack(s) are outdated, %d pack(s) could not be checked", len(outdated), failed)
+	case len(outdated) > 0:
+		return fmt.Errorf("%d pack(s) are outdated", len(outdated))
+	}
+	return nil
+}
+
+// check compares the version of the pack installed in the directory dir with
+// the versions available in the repository it was installed from.
+//
+// It returns nil if the pack is up to date or was not installed from a
+// repository.
+func (oc *outdatedCmd) check(dir string, rf *repo.RepoFile) (*outdatedPack, error) {
+	name, _ := repo.ParsePackDirName(dir)
+	repoName, packName, index, err := findInstalledPack(oc.home, rf, name)
+	if err == errNotFromRepository {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	installed, err := pack.FromDir(filepath.Join(oc.home.Packs(), dir))
+	if err != nil {
+		return nil, err
+	}
+	if installed.Metadata == nil || installed.Metadata.Version == "" {
+		return nil, fmt.Errorf("installed pack has no version")
+	}
+	current, err := semver.NewVersion(installed.Metadata.Version)
+	if err != nil {
+		return nil, fmt.Errorf("installed pack has an invalid version: %s", err)
+	}
+
+	cv, err := index.Get(packName, "")
+	if err != nil {
+		return nil, err
+	}
+	latest, err := semver.NewVersion(cv.Version)
+	if err != nil {
+		return nil, err
+	}
+	if !latest.GreaterThan(current) {
+		return nil, nil
+	}
+
+	o := &outdatedPack{
+		Name:       name,
+		Repository: repoName,
+		Installed:  current.String(),
+		Latest:     latest.String(),
+	}
+	if oc.version != "" {
+		if wv, err := index.Get(packName, oc.version); err == nil {
+			o.Wanted = wv.Version
+		}
+	}
+	return o, nil
+}
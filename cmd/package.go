@@ -15,10 +15,18 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"syscall"
 
 	"github.com/Azure/draft/pkg/draft/pack"
 	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+	"k8s.io/helm/pkg/provenance"
+
+	"github.com/rodcloutier/draft-packs/pkg/packutil"
 )
 
 const packPackageDesc = `
@@ -31,11 +39,18 @@ If no path is given, this will look in the present working directory for a
 pack, and (if found) build the current directory into a pack.
 
 Pack archives are used by Draft package repositories.
+
+With --sign, a provenance file is generated next to the archive. It holds the
+pack metadata and the digest of the archive, signed with the PGP private key
+--key found in --keyring. It allows the pack to be installed with --verify.
 `
 
 type packPackageCmd struct {
 	destination string
 	path        string
+	sign        bool
+	key         string
+	keyring     string
 }
 
 func init() {
@@ -49,6 +64,9 @@ func init() {
 			if len(args) == 0 {
 				return fmt.Errorf("need at least on argument, the path to the pack")
 			}
+			if pc.sign && pc.key == "" {
+				return errors.New("--key is required for signing a package")
+			}
 
 			for i := 0; i < len(args); i++ {
 				pc.path = args[i]
@@ -62,6 +80,9 @@ func init() {
 
 	f := cmd.Flags()
 	f.StringVarP(&pc.destination, "destination", "d", ".", "location to write the pack")
+	f.BoolVar(&pc.sign, "sign", false, "use a PGP private key to sign this package")
+	f.StringVar(&pc.key, "key", "", "name of the key to use when signing. Used if --sign is true")
+	f.StringVar(&pc.keyring, "keyring", defaultSecretKeyring(), "location of a secret keyring holding the signing key")
 	RootCmd.AddCommand(cmd)
 }
 
@@ -77,6 +98,43 @@ func (pc *packPackageCmd) run() error {
 		return err
 	}
 
+	if pc.sign {
+		if err := pc.clearsign(fullPath, pck); err != nil {
+			return err
+		}
+	}
+
 	fmt.Printf("--> Packaged pack '%s' ready to be shipped!\n", fullPath)
 	return nil
 }
+
+// clearsign writes the signed provenance file of the archive of pck.
+func (pc *packPackageCmd) clearsign(archive string, pck *pack.Pack) error {
+	signatory, err := provenance.NewFromKeyring(pc.keyring, pc.key)
+	if err != nil {
+		return err
+	}
+	if err := signatory.DecryptKey(promptUser); err != nil {
+		return err
+	}
+
+	sig, err := packutil.Sign(archive, pck.Metadata, signatory)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(archive+packutil.ProvenanceExt, []byte(sig), 0644)
+}
+
+// defaultSecretKeyring returns the expanded path to the default secret
+// keyring.
+func defaultSecretKeyring() string {
+	return os.ExpandEnv("$HOME/.gnupg/secring.gpg")
+}
+
+// promptUser prompts for the passphrase of the key name.
+func promptUser(name string) ([]byte, error) {
+	fmt.Printf("Password for key %q >  ", name)
+	pw, err := terminal.ReadPassword(int(syscall.Stdin))
+	fmt.Println()
+	return pw, err
+}
@@ -0,0 +1,104 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rodcloutier/draft-packs/pkg/draftpath"
+	"github.com/rodcloutier/draft-packs/pkg/repo"
+)
+
+const priorityDesc = `
+This command manages the priority of the installed packs.
+
+Draft reads the installed packs in alphabetical order and uses the first pack
+whose detect script succeeds. The priority of a pack is written as a prefix of
+its directory in $DRAFT_HOME/packs, so that packs with a lower priority value
+are detected first. Packs without priority are detected after the others.
+
+Use 'draft packs list' to show the resulting detection order.
+`
+
+type prioritySetCmd struct {
+	home     draftpath.Home
+	name     string
+	priority int
+}
+
+func init() {
+	ps := &prioritySetCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "priority",
+		Short: "manage the detection priority of installed packs",
+		Long:  priorityDesc,
+	}
+
+	set := &cobra.Command{
+		Use:   "set [PACK] [PRIORITY]",
+		Short: "set the priority of an installed pack, 0 removes it",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				return errors.New("missing at least one expected args PACK and/or PRIORITY")
+			}
+			priority, err := strconv.Atoi(args[1])
+			if err != nil || priority < 0 || priority > repo.MaxPriority {
+				return fmt.Errorf("priority must be a number between 0 and %d", repo.MaxPriority)
+			}
+			ps.name = args[0]
+			ps.priority = priority
+			ps.home = draftpath.NewHome(homePath())
+			return ps.run()
+		},
+	}
+
+	cmd.AddCommand(set)
+	RootCmd.AddCommand(cmd)
+}
+
+func (ps *prioritySetCmd) run() error {
+	_, dir, err := findInstalled(ps.home, ps.name)
+	if err == repo.ErrPackNotInstalled {
+		return fmt.Errorf("Failed to find pack %s", ps.name)
+	} else if err != nil {
+		return err
+	}
+
+	// The pack may have been given by its directory name
+	name, _ := repo.ParsePackDirName(dir)
+	newDir := repo.PackDirName(name, ps.priority)
+	if newDir != dir {
+		if _, err := os.Stat(filepath.Join(ps.home.Packs(), newDir)); err == nil {
+			return fmt.Errorf("%s already exists", newDir)
+		}
+		if err := os.Rename(filepath.Join(ps.home.Packs(), dir), filepath.Join(ps.home.Packs(), newDir)); err != nil {
+			return err
+		}
+	}
+
+	if ps.priority == 0 {
+		fmt.Printf("Priority of pack %s removed\n", name)
+	} else {
+		fmt.Printf("Priority of pack %s set to %d\n", name, ps.priority)
+	}
+	return nil
+}
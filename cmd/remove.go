@@ -19,9 +19,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/rodcloutier/draft-packs/pkg/draftpath"
+	"github.com/rodcloutier/draft-packs/pkg/repo"
 	"github.com/spf13/cobra"
 )
 
@@ -37,7 +37,7 @@ func init() {
 
 	cmd := &cobra.Command{
 		Use:   "remove [PACK]",
-		Short: "remove an installed pack",
+		Short: "remove an installed pack, given its installed name or the reference it was installed from",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) != 1 {
 				return errors.New("Missing expected argument PACK name")
@@ -50,17 +50,49 @@ func init() {
 
 func (rc *packRemoveCmd) run(name string) error {
 
-	name = strings.Replace(name, "/", "-", -1)
-
-	packPath := filepath.Join(rc.home.Packs(), name)
-	if _, err := os.Stat(packPath); os.IsNotExist(err) {
+	installName, dir, err := findInstalled(rc.home, name)
+	if err == repo.ErrPackNotInstalled {
 		return fmt.Errorf("Failed to find pack %s", name)
+	} else if err != nil {
+		return err
 	}
+	name = installName
+
+	rc.warnDependents(name)
 
-	err := os.RemoveAll(packPath)
+	packPath := filepath.Join(rc.home.Packs(), dir)
+	err = os.RemoveAll(packPath)
 	if err != nil {
 		return fmt.Errorf("There was an error deleting pack %s", packPath)
 	}
 
+	if err := os.Remove(rc.home.InstalledPack(name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("There was an error deleting the install record of pack %s: %s", name, err)
+	}
+
 	return nil
 }
+
+// warnDependents warns about the installed packs that still require the
+// pack installed as name.
+func (rc *packRemoveCmd) warnDependents(name string) {
+	ref := name
+	if record, err := repo.LoadInstalledPack(rc.home.InstalledPack(name)); err == nil && record.Repository != "" {
+		ref = record.Reference
+	}
+
+	records, err := repo.LoadInstalledPacks(rc.home.Installed())
+	if err != nil {
+		return
+	}
+	for _, r := range records {
+		if r.Name == name {
+			continue
+		}
+		for _, d := range r.Dependencies {
+			if d == ref {
+				fmt.Printf("WARNING: pack %s is still required by %s\n", ref, r.Name)
+			}
+		}
+	}
+}
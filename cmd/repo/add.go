@@ -21,8 +21,6 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"k8s.io/helm/pkg/getter"
-
 	"github.com/rodcloutier/draft-packs/pkg/draftpath"
 	. "github.com/rodcloutier/draft-packs/pkg/getter"
 	"github.com/rodcloutier/draft-packs/pkg/repo"
@@ -37,6 +35,14 @@ type repoAddCmd struct {
 	certFile string
 	keyFile  string
 	caFile   string
+
+	verify       string
+	fingerprints []string
+
+	username         string
+	password         string
+	token            string
+	credentialHelper string
 }
 
 func init() {
@@ -53,6 +59,13 @@ func init() {
 				return errors.New("missing at least one expected args NAME and/or URL")
 			}
 
+			if err := repo.ValidateVerify(add.verify); err != nil {
+				return err
+			}
+			if add.token != "" && (add.username != "" || add.password != "") {
+				return errors.New("--token cannot be used with --username and --password")
+			}
+
 			add.name = args[0]
 			add.url = args[1]
 			add.home = draftpath.NewHome(os.ExpandEnv("$DRAFT_HOME"))
@@ -66,19 +79,40 @@ func init() {
 	f.StringVar(&add.certFile, "cert-file", "", "identify HTTPS client using this SSL certificate file")
 	f.StringVar(&add.keyFile, "key-file", "", "identify HTTPS client using this SSL key file")
 	f.StringVar(&add.caFile, "ca-file", "", "verify certificates of HTTPS-enabled servers using this CA bundle")
+	f.StringVar(&add.verify, "verify", "", "verification policy of the packs of the repository, one of: never, if-possible, always")
+	f.StringSliceVar(&add.fingerprints, "fingerprint", nil, "fingerprint of a key allowed to sign the packs of the repository. Can be repeated")
+	f.StringVar(&add.username, "username", "", "username for the basic authentication to the repository")
+	f.StringVar(&add.password, "password", "", "password for the basic authentication to the repository")
+	f.StringVar(&add.token, "token", "", "bearer token for the authentication to the repository")
+	f.StringVar(&add.credentialHelper, "credential-helper", "", "name of the credential helper storing the credentials, run as draft-credential-NAME. Defaults to an encrypted store in $DRAFT_HOME")
 
 	RootCmd.AddCommand(cmd)
 }
 
 func (a *repoAddCmd) run() error {
-	if err := addRepository(a.name, a.url, a.home, a.certFile, a.keyFile, a.caFile, a.noupdate); err != nil {
+	c := &repo.Entry{
+		Name:         a.name,
+		URL:          a.url,
+		CertFile:     a.certFile,
+		KeyFile:      a.keyFile,
+		CAFile:       a.caFile,
+		Verify:       a.verify,
+		Fingerprints: a.fingerprints,
+		Username:     a.username,
+		Password:     a.password,
+		Token:        a.token,
+		Credentials:  a.credentialHelper,
+	}
+	if err := AddRepository(c, a.home, a.noupdate); err != nil {
 		return err
 	}
 	fmt.Printf("%q has been added to your repositories\n", a.name)
 	return nil
 }
 
-func addRepository(name, url string, home draftpath.Home, certFile, keyFile, caFile string, noUpdate bool) error {
+// AddRepository adds the repository c to the repositories file of home, or
+// updates it if it already exists, and downloads its index.
+func AddRepository(c *repo.Entry, home draftpath.Home, noUpdate bool) error {
 
 	if _, err := os.Stat(home.RepositoryFile()); os.IsNotExist(err) {
 		err = os.MkdirAll(home.Repository(), os.ModePerm)
@@ -99,28 +133,20 @@ func addRepository(name, url string, home draftpath.Home, certFile, keyFile, caF
 		}
 	}
 
-	if noUpdate && f.Has(name) {
-		return fmt.Errorf("repository name (%s) already exists, please specify a different name", name)
+	if noUpdate && f.Has(c.Name) {
+		return fmt.Errorf("repository name (%s) already exists, please specify a different name", c.Name)
 	}
 
-	cif := home.CacheIndex(name)
-	c := repo.Entry{
-		Name:     name,
-		Cache:    cif,
-		URL:      url,
-		CertFile: certFile,
-		KeyFile:  keyFile,
-		CAFile:   caFile,
-	}
+	c.Cache = home.CacheIndex(c.Name)
 
-	providers := getter.Providers{
-		{
-			Schemes: []string{"http", "https"},
-			New:     NewHTTPGetter,
-		},
-	}
+	// The entry is given the credential store of the repositories file, and
+	// keeps the credentials of the existing repository unless given others
+	old := f.Get(c.Name)
+	f.Update(c)
 
-	r, err := repo.NewRepository(&c, providers)
+	providers := AllProviders()
+
+	r, err := repo.NewRepository(c, providers)
 	if err != nil {
 		return err
 	}
@@ -133,10 +159,18 @@ func addRepository(name, url string, home draftpath.Home, certFile, keyFile, caF
 	}
 
 	if err := r.DownloadIndexFile(home.Cache()); err != nil {
-		return fmt.Errorf("Looks like %q is not a valid pack repository or cannot be reached: %s", url, err.Error())
+		return fmt.Errorf("Looks like %q is not a valid pack repository or cannot be reached: %s", c.URL, err.Error())
 	}
 
-	f.Update(&c)
+	if err := f.WriteFile(home.RepositoryFile(), 0644); err != nil {
+		return err
+	}
 
-	return f.WriteFile(home.RepositoryFile(), 0644)
+	// Erase the credentials the repository no longer uses
+	if old != nil && old.Credentials != "" && old.Credentials != c.Credentials {
+		if err := old.EraseCredentials(); err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: previous credentials of %s could not be erased: %s\n", c.Name, err)
+		}
+	}
+	return nil
 }
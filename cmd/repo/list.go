@@ -60,9 +60,16 @@ func (a *repoListCmd) run() error {
 		return errors.New("no repositories to show")
 	}
 	table := uitable.New()
-	table.AddRow("NAME", "URL")
+	table.AddRow("NAME", "URL", "VERIFY")
 	for _, re := range f.Repositories {
-		table.AddRow(re.Name, re.URL)
+		verify := re.Verify
+		if verify == "" {
+			verify = repo.VerifyNever
+		}
+		if len(re.Fingerprints) > 0 {
+			verify = fmt.Sprintf("%s, %d pinned key(s)", verify, len(re.Fingerprints))
+		}
+		table.AddRow(re.Name, re.URL, verify)
 	}
 	fmt.Println(table)
 	return nil
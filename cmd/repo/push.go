@@ -0,0 +1,168 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/Azure/draft/pkg/draft/pack"
+	"github.com/spf13/cobra"
+
+	"github.com/rodcloutier/draft-packs/pkg/draftpath"
+	. "github.com/rodcloutier/draft-packs/pkg/getter"
+	"github.com/rodcloutier/draft-packs/pkg/oci"
+	"github.com/rodcloutier/draft-packs/pkg/repo"
+)
+
+const repoPushDesc = `
+Push a packaged pack to an OCI registry.
+
+The destination is a repository added with an oci:// URL, or the oci:// URL of
+a namespace of a registry, such as oci://registry.example.com/packs. The pack is
+stored as NAMESPACE/NAME, tagged with its version, and with its provenance file
+if one is found next to the archive.
+
+The credentials of the repository, or of the repository added with the same
+URL, are used unless others are given.
+`
+
+type repoPushCmd struct {
+	archive string
+	dest    string
+	home    draftpath.Home
+
+	username string
+	password string
+	token    string
+}
+
+func init() {
+
+	push := &repoPushCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "push [flags] [PACK.tgz] [REPO|oci://REGISTRY/NAMESPACE]",
+		Short: "push a packaged pack to an OCI registry",
+		Long:  repoPushDesc,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				return errors.New("missing at least one expected args PACK and/or REPO")
+			}
+			if push.token != "" && (push.username != "" || push.password != "") {
+				return errors.New("--token cannot be used with --username and --password")
+			}
+			push.archive = args[0]
+			push.dest = args[1]
+			push.home = draftpath.NewHome(os.ExpandEnv("$DRAFT_HOME"))
+
+			return push.run()
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&push.username, "username", "", "username for the authentication to the registry")
+	f.StringVar(&push.password, "password", "", "password for the authentication to the registry")
+	f.StringVar(&push.token, "token", "", "bearer token for the authentication to the registry")
+
+	RootCmd.AddCommand(cmd)
+}
+
+func (p *repoPushCmd) run() error {
+	pk, err := pack.Load(p.archive)
+	if err != nil {
+		return err
+	}
+	if pk == nil || pk.Metadata == nil || pk.Metadata.Name == "" || pk.Metadata.Version == "" {
+		return fmt.Errorf("pack %s has no name or version", p.archive)
+	}
+
+	c, namespace, err := p.registry()
+	if err != nil {
+		return err
+	}
+	ref, err := oci.ParseReference(namespace + "/" + pk.Metadata.Name + ":" + oci.TagFromVersion(pk.Metadata.Version))
+	if err != nil {
+		return err
+	}
+
+	config, err := json.Marshal(pk.Metadata)
+	if err != nil {
+		return err
+	}
+	archive, err := ioutil.ReadFile(p.archive)
+	if err != nil {
+		return err
+	}
+	prov, err := ioutil.ReadFile(p.archive + ".prov")
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	digest, err := c.Push(ref, config, archive, prov)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Pushed %s to %s\nDigest: %s\n", p.archive, ref, digest)
+	return nil
+}
+
+// registry returns the client of the registry of the destination, with its
+// credentials, and the URL of the namespace the pack is pushed to.
+func (p *repoPushCmd) registry() (*oci.Client, string, error) {
+	f, err := repo.LoadRepositoriesFile(p.home.RepositoryFile())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, "", err
+	}
+
+	var entry *repo.Entry
+	if f != nil {
+		for _, e := range f.Repositories {
+			if e.Name == p.dest || strings.TrimSuffix(e.URL, "/") == strings.TrimSuffix(p.dest, "/") {
+				entry = e
+				break
+			}
+		}
+	}
+	if entry == nil {
+		if !strings.HasPrefix(p.dest, oci.Scheme+"://") {
+			return nil, "", fmt.Errorf("no OCI repository named %q found", p.dest)
+		}
+		entry = &repo.Entry{URL: p.dest}
+	}
+	if !strings.HasPrefix(entry.URL, oci.Scheme+"://") {
+		return nil, "", fmt.Errorf("repository %s is not an OCI registry", entry.Name)
+	}
+
+	r, err := repo.NewRepository(entry, AllProviders())
+	if err != nil {
+		return nil, "", err
+	}
+	og, ok := r.Client.(*OCIGetter)
+	if !ok {
+		return nil, "", fmt.Errorf("Could not find a registry client for: %s", entry.URL)
+	}
+	if p.username != "" || p.password != "" {
+		og.SetCredentials(p.username, p.password)
+	}
+	if p.token != "" {
+		og.SetToken(p.token)
+	}
+	return og.Registry(), strings.TrimSuffix(entry.URL, "/"), nil
+}
@@ -63,6 +63,14 @@ func removeRepoLine(name string, home draftpath.Home) error {
 		return err
 	}
 
+	for _, e := range r.Repositories {
+		if e.Name != name {
+			continue
+		}
+		if err := e.EraseCredentials(); err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: credentials of %s could not be erased: %s\n", name, err)
+		}
+	}
 	if !r.Remove(name) {
 		return fmt.Errorf("no repo named %q found", name)
 	}
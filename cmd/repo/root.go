@@ -21,13 +21,14 @@ import (
 var repoDraft = `
 This command consts of multiple subcommands to interact with pack repositories.
 
-It can be used to add, remove, list, update, and index pack repositories.
+It can be used to add, remove, list, update, and index pack repositories, and
+to push packs to OCI registries.
 Example usage:
     $ draft repo add [NAME] [REPO_URL]
 `
 
 var RootCmd = &cobra.Command{
-	Use:   "repo [FLAGS] add|remove|list|index|update [ARGS]",
-	Short: "add, list, remove, update and index pack repositories",
+	Use:   "repo [FLAGS] add|remove|list|index|update|push [ARGS]",
+	Short: "add, list, remove, update, index and push to pack repositories",
 	Long:  repoDraft,
 }
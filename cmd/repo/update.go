@@ -22,8 +22,6 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"k8s.io/helm/pkg/getter"
-
 	"github.com/rodcloutier/draft-packs/pkg/draftpath"
 	. "github.com/rodcloutier/draft-packs/pkg/getter"
 	"github.com/rodcloutier/draft-packs/pkg/repo"
@@ -73,12 +71,7 @@ func (u *repoUpdateCmd) run() error {
 	var repos []*repo.PackRepository
 	for _, cfg := range f.Repositories {
 
-		providers := getter.Providers{
-			{
-				Schemes: []string{"http", "https"},
-				New:     NewHTTPGetter,
-			},
-		}
+		providers := AllProviders()
 
 		r, err := repo.NewRepository(cfg, providers)
 		if err != nil {
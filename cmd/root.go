@@ -20,6 +20,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/rodcloutier/draft-packs/cmd/keys"
 	"github.com/rodcloutier/draft-packs/cmd/repo"
 )
 
@@ -50,6 +51,7 @@ func init() {
 
 	RootCmd.SilenceUsage = true
 	RootCmd.AddCommand(repo.RootCmd)
+	RootCmd.AddCommand(keys.RootCmd)
 }
 
 // Execute adds all child commands to the root command sets flags appropriately.
@@ -0,0 +1,306 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure/draft/pkg/draft/pack"
+	"github.com/spf13/cobra"
+
+	cmdrepo "github.com/rodcloutier/draft-packs/cmd/repo"
+	"github.com/rodcloutier/draft-packs/pkg/draftpath"
+	"github.com/rodcloutier/draft-packs/pkg/repo"
+)
+
+const syncDesc = `
+This command sets up $DRAFT_HOME as declared by a pack manifest.
+
+The repositories declared by the manifest are added, or updated, and their
+indexes downloaded. The packs declared by the manifest are then installed,
+upgraded or downgraded to the latest version matching their version
+constraint, and given their priority. The packs installed from a repository
+that are neither declared by the manifest nor required by another pack are
+removed. Packs installed from a local path, as well as the builtin packs, are
+left untouched.
+
+The manifest is read from draft-packs.yaml by default:
+
+    apiVersion: v1
+    repositories:
+    - name: stable
+      url: https://example.com/packs
+    packs:
+    - name: stable/golang
+      version: ^1.2.0
+      priority: 10
+
+Use --dry-run to print the plan without changing anything.
+`
+
+type syncCmd struct {
+	home    draftpath.Home
+	file    string
+	dryRun  bool
+	verify  bool
+	keyring string
+}
+
+// syncAction is a step bringing $DRAFT_HOME in line with the manifest.
+type syncAction struct {
+	description string
+	apply       func() error
+}
+
+func init() {
+	sc := &syncCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "sync [flags]",
+		Short: "install the repositories and packs declared by a manifest",
+		Long:  syncDesc,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return errors.New("sync does not accept arguments, use --file to select the manifest")
+			}
+			sc.home = draftpath.NewHome(homePath())
+			return sc.run()
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&sc.file, "file", "f", repo.ManifestFile, "path to the pack manifest")
+	f.BoolVar(&sc.dryRun, "dry-run", false, "print the plan without applying it")
+	f.BoolVar(&sc.verify, "verify", false, "verify the packages before installing them")
+	f.StringVar(&sc.keyring, "keyring", defaultKeyring(), "location of public keys used for verification")
+
+	RootCmd.AddCommand(cmd)
+}
+
+func (sc *syncCmd) run() error {
+	m, err := repo.LoadManifest(sc.file)
+	if err != nil {
+		return fmt.Errorf("unable to load manifest %s: %s", sc.file, err)
+	}
+
+	repoActions, err := sc.planRepositories(m)
+	if err != nil {
+		return err
+	}
+	fmt.Println("==> Repositories")
+	if err := sc.apply(repoActions); err != nil {
+		return err
+	}
+
+	packActions, err := sc.planPacks(m)
+	if err != nil {
+		return err
+	}
+	fmt.Println("==> Packs")
+	return sc.apply(packActions)
+}
+
+// apply prints the actions and, unless running dry, applies them.
+func (sc *syncCmd) apply(actions []*syncAction) error {
+	if len(actions) == 0 {
+		fmt.Println("...Nothing to do")
+		return nil
+	}
+
+	failed := 0
+	for _, a := range actions {
+		fmt.Printf("...%s\n", a.description)
+		if sc.dryRun {
+			continue
+		}
+		if err := a.apply(); err != nil {
+			fmt.Printf("...Failed: %s\n", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d action(s) failed", failed)
+	}
+	return nil
+}
+
+// planRepositories returns the actions adding or updating the repositories
+// declared by the manifest m.
+//
+// The indexes of the repositories are always downloaded again, so that the
+// latest versions of the packs are known.
+func (sc *syncCmd) planRepositories(m *repo.Manifest) ([]*syncAction, error) {
+	rf, err := repo.LoadRepositoriesFile(sc.home.RepositoryFile())
+	if os.IsNotExist(err) {
+		rf, err = repo.NewRepoFile(), nil
+	}
+	if err != nil && err != repo.ErrRepoOutOfDate {
+		return nil, err
+	}
+
+	actions := []*syncAction{}
+	for _, mr := range m.Repositories {
+		mr := mr
+		description := fmt.Sprintf("Add repository %s (%s)", mr.Name, mr.URL)
+		for _, e := range rf.Repositories {
+			if e.Name != mr.Name {
+				continue
+			}
+			if sameRepository(e, mr.Entry()) {
+				description = fmt.Sprintf("Update index of repository %s", mr.Name)
+			} else {
+				description = fmt.Sprintf("Update repository %s (%s)", mr.Name, mr.URL)
+			}
+		}
+		actions = append(actions, &syncAction{
+			description: description,
+			apply: func() error {
+				return cmdrepo.AddRepository(mr.Entry(), sc.home, false)
+			},
+		})
+	}
+	return actions, nil
+}
+
+// sameRepository returns whether the repositories a and b have the same
+// settings. The credentials are not compared: manifests do not declare them,
+// and the existing repository keeps its own.
+func sameRepository(a, b *repo.Entry) bool {
+	if a.URL != b.URL || a.CertFile != b.CertFile || a.KeyFile != b.KeyFile || a.CAFile != b.CAFile || a.Verify != b.Verify {
+		return false
+	}
+	if len(a.Fingerprints) != len(b.Fingerprints) {
+		return false
+	}
+	for i := range a.Fingerprints {
+		if repo.NormalizeFingerprint(a.Fingerprints[i]) != repo.NormalizeFingerprint(b.Fingerprints[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// planPacks returns the actions installing the packs declared by the
+// manifest m and removing the packs installed from a repository that it does
+// not declare.
+//
+// The versions to install are read from the cached repository indexes.
+func (sc *syncCmd) planPacks(m *repo.Manifest) ([]*syncAction, error) {
+	actions := []*syncAction{}
+	declared := map[string]bool{}
+	for _, mp := range m.Packs {
+		mp := mp
+		name := mp.InstallName()
+		declared[name] = true
+
+		index, err := repo.LoadIndexFile(sc.home.CacheIndex(mp.Repository()))
+		if err != nil {
+			if sc.dryRun {
+				actions = append(actions, &syncAction{description: fmt.Sprintf("Install %s as %s, once repository %s is added", mp.Name, name, mp.Repository())})
+				continue
+			}
+			return nil, fmt.Errorf("no cached repo found for pack %s. (try 'draft packs repo update'). %s", mp.Name, err)
+		}
+		index.SortEntries()
+		cv, err := index.Get(mp.PackName(), mp.Version)
+		if err != nil {
+			return nil, fmt.Errorf("pack %s: %s", mp.Name, err)
+		}
+
+		dir, err := repo.FindPackDir(sc.home.Packs(), name)
+		if err == repo.ErrPackNotInstalled || os.IsNotExist(err) {
+			actions = append(actions, &syncAction{
+				description: fmt.Sprintf("Install %s %s as %s", mp.Name, cv.Version, name),
+				apply: func() error {
+					if err := os.MkdirAll(sc.home.Packs(), 0755); err != nil {
+						return err
+					}
+					return installPackVersion(sc.home, mp.Repository(), mp.PackName(), cv, name, repo.PackDirName(name, mp.Priority), sc.verify, sc.keyring)
+				},
+			})
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		current, reference := installedVersion(sc.home, name, dir)
+		if current != cv.Version || reference != mp.Name {
+			description := fmt.Sprintf("Change %s from %s to %s %s", name, current, mp.Name, cv.Version)
+			if reference == mp.Name {
+				description = fmt.Sprintf("Change %s from %s to %s", name, current, cv.Version)
+			}
+			actions = append(actions, &syncAction{
+				description: description,
+				apply: func() error {
+					return installPackVersion(sc.home, mp.Repository(), mp.PackName(), cv, name, dir, sc.verify, sc.keyring)
+				},
+			})
+		}
+
+		if _, priority := repo.ParsePackDirName(dir); priority != mp.Priority {
+			ps := &prioritySetCmd{home: sc.home, name: name, priority: mp.Priority}
+			description := fmt.Sprintf("Set priority of %s to %d", name, mp.Priority)
+			if mp.Priority == 0 {
+				description = fmt.Sprintf("Remove priority of %s", name)
+			}
+			actions = append(actions, &syncAction{
+				description: description,
+				apply:       ps.run,
+			})
+		}
+	}
+
+	records, err := repo.LoadInstalledPacks(sc.home.Installed())
+	if err != nil {
+		return nil, err
+	}
+	// Packs installed as dependencies of other packs are kept
+	required := map[string]bool{}
+	for _, r := range records {
+		for _, d := range r.Dependencies {
+			required[d] = true
+		}
+	}
+	for _, r := range records {
+		if r.Repository == "" || declared[r.Name] || required[r.Reference] {
+			continue
+		}
+		if _, err := repo.FindPackDir(sc.home.Packs(), r.Name); err != nil {
+			continue
+		}
+		rc := &packRemoveCmd{home: sc.home}
+		name := r.Name
+		actions = append(actions, &syncAction{
+			description: fmt.Sprintf("Remove %s", name),
+			apply:       func() error { return rc.run(name) },
+		})
+	}
+	return actions, nil
+}
+
+// installedVersion returns the version of the pack installed as name in the
+// directory dir, and the reference it was installed from.
+func installedVersion(home draftpath.Home, name, dir string) (string, string) {
+	if record, err := repo.LoadInstalledPack(home.InstalledPack(name)); err == nil {
+		return record.Version, record.Reference
+	}
+	if p, err := pack.FromDir(filepath.Join(home.Packs(), dir)); err == nil && p.Metadata != nil {
+		return p.Metadata.Version, ""
+	}
+	return "", ""
+}
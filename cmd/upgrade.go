@@ -0,0 +1,262 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/draft/pkg/draft/pack"
+	"github.com/Masterminds/semver"
+	"github.com/spf13/cobra"
+
+	"github.com/rodcloutier/draft-packs/pkg/downloader"
+	"github.com/rodcloutier/draft-packs/pkg/draftpath"
+	. "github.com/rodcloutier/draft-packs/pkg/getter"
+	"github.com/rodcloutier/draft-packs/pkg/installer"
+	"github.com/rodcloutier/draft-packs/pkg/repo"
+)
+
+const upgradeDesc = `
+This command upgrades installed packs to the newest version available in the
+pack repositories.
+
+The versions available are read from the cached repository indexes, run
+'draft packs repo update' beforehand to get the latest information.
+
+Use --version to restrict the upgrade to the versions matching a semantic
+versioning constraint, and --all to upgrade every installed pack.
+`
+
+type upgradeCmd struct {
+	home    draftpath.Home
+	names   []string
+	all     bool
+	version string
+	verify  bool
+	keyring string
+}
+
+func init() {
+	uc := &upgradeCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "upgrade [flags] [PACK...]",
+		Short: "upgrade installed packs",
+		Long:  upgradeDesc,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && !uc.all {
+				return errors.New("Missing expected argument PACK name, or --all")
+			}
+			if len(args) > 0 && uc.all {
+				return errors.New("PACK names cannot be specified with --all")
+			}
+			uc.names = args
+			uc.home = draftpath.NewHome(homePath())
+			return uc.run()
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVar(&uc.all, "all", false, "upgrade all the packs installed from a repository")
+	f.StringVarP(&uc.version, "version", "v", "", "semantic versioning constraint the new version must match. If this is not specified, the latest version is installed")
+	f.BoolVar(&uc.verify, "verify", false, "verify the packages before upgrading")
+	f.StringVar(&uc.keyring, "keyring", defaultKeyring(), "location of public keys used for verification")
+
+	RootCmd.AddCommand(cmd)
+}
+
+func (uc *upgradeCmd) run() error {
+	names := uc.names
+	if uc.all {
+		files, err := ioutil.ReadDir(uc.home.Packs())
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if f.IsDir() {
+				name, _ := repo.ParsePackDirName(f.Name())
+				names = append(names, name)
+			}
+		}
+	}
+
+	rf, err := repo.LoadRepositoriesFile(uc.home.RepositoryFile())
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, name := range names {
+		if err := uc.upgrade(name, rf); err != nil {
+			fmt.Printf("...Unable to upgrade pack %s: %s\n", name, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d pack(s) could not be upgraded", failed)
+	}
+	return nil
+}
+
+func (uc *upgradeCmd) upgrade(name string, rf *repo.RepoFile) error {
+	installName, dir, err := findInstalled(uc.home, name)
+	if err != nil {
+		return err
+	}
+	packPath := filepath.Join(uc.home.Packs(), dir)
+
+	installed, err := pack.FromDir(packPath)
+	if err != nil {
+		return err
+	}
+	if installed.Metadata == nil || installed.Metadata.Version == "" {
+		return errors.New("installed pack has no version")
+	}
+	current, err := semver.NewVersion(installed.Metadata.Version)
+	if err != nil {
+		return fmt.Errorf("installed pack has an invalid version: %s", err)
+	}
+
+	repoName, packName, index, err := findInstalledPack(uc.home, rf, installName)
+	if err != nil {
+		if uc.all && err == errNotFromRepository {
+			fmt.Printf("...Skipping pack %s: not installed from a repository\n", name)
+			return nil
+		}
+		return err
+	}
+
+	cv, err := index.Get(packName, uc.version)
+	if err != nil {
+		return err
+	}
+	latest, err := semver.NewVersion(cv.Version)
+	if err != nil {
+		return err
+	}
+	if !latest.GreaterThan(current) {
+		fmt.Printf("...Pack %s is up to date (%s)\n", name, current)
+		return nil
+	}
+
+	if err := installPackVersion(uc.home, repoName, packName, cv, installName, dir, uc.verify, uc.keyring); err != nil {
+		return err
+	}
+
+	fmt.Printf("...Pack %s upgraded from %s to %s\n", name, current, latest)
+	return nil
+}
+
+// errNotFromRepository indicates that an installed pack was not installed
+// from a known repository.
+var errNotFromRepository = errors.New("not installed from a repository")
+
+// findInstalledPack finds the repository the pack installed as name comes
+// from, using its install record or, for packs installed before install
+// records existed, the pack being named repo-pack.
+//
+// It returns the name of the repository, the name of the pack in the
+// repository and the cached index of the repository.
+func findInstalledPack(home draftpath.Home, rf *repo.RepoFile, name string) (string, string, *repo.IndexFile, error) {
+	if record, err := repo.LoadInstalledPack(home.InstalledPack(name)); err == nil && record.Repository != "" {
+		i, err := repo.LoadIndexFile(home.CacheIndex(record.Repository))
+		if err != nil {
+			return "", "", nil, fmt.Errorf("no cached repo found. (try 'draft packs repo update'). %s", err)
+		}
+		i.SortEntries()
+		return record.Repository, strings.TrimPrefix(record.Reference, record.Repository+"/"), i, nil
+	}
+
+	for _, re := range rf.Repositories {
+		prefix := re.Name + "-"
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		i, err := repo.LoadIndexFile(home.CacheIndex(re.Name))
+		if err != nil {
+			return "", "", nil, fmt.Errorf("repo %q is corrupt or missing. (try 'draft packs repo update'). %s", re.Name, err)
+		}
+
+		packName := strings.TrimPrefix(name, prefix)
+		if _, ok := i.Entries[packName]; ok {
+			i.SortEntries()
+			return re.Name, packName, i, nil
+		}
+	}
+	return "", "", nil, errNotFromRepository
+}
+
+// installPackVersion downloads the version cv of the pack packName of the
+// repository repoName and installs it as name in the directory dir of the
+// packs directory, replacing any pack installed there.
+func installPackVersion(home draftpath.Home, repoName, packName string, cv *repo.PackVersion, name, dir string, verify bool, keyring string) error {
+	dl := downloader.Downloader{
+		Home:    home,
+		Out:     os.Stdout,
+		Keyring: keyring,
+		Getters: AllProviders(),
+	}
+	if verify {
+		dl.Verify = downloader.VerifyAlways
+	}
+
+	if _, err := os.Stat(home.Archive()); os.IsNotExist(err) {
+		os.MkdirAll(home.Archive(), 0744)
+	}
+
+	ref := repoName + "/" + packName
+	filename, ver, err := dl.DownloadTo(ref, cv.Version, home.Archive())
+	if err != nil {
+		return err
+	}
+
+	p, err := pack.Load(filename)
+	if err != nil {
+		return err
+	}
+
+	deps, err := installDependencies(home, repoName, ref, filename, verify, keyring)
+	if err != nil {
+		return err
+	}
+
+	if err := installer.Install(home, dir, p); err != nil {
+		return err
+	}
+
+	record, err := repo.LoadInstalledPack(home.InstalledPack(name))
+	if err != nil {
+		record = repo.NewInstalledPack(name)
+	}
+	record.Reference = ref
+	record.Repository = repoName
+	record.Verified = false
+	record.SignedBy = ""
+	if len(cv.URLs) > 0 {
+		record.URL = cv.URLs[0]
+	}
+	record.Dependencies = nil
+	for _, d := range deps {
+		record.Dependencies = append(record.Dependencies, d.Name)
+	}
+	setVerification(record, ver)
+	return writeInstallRecord(home, name, record, filename, p)
+}
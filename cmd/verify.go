@@ -0,0 +1,154 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"k8s.io/helm/pkg/provenance"
+
+	"github.com/rodcloutier/draft-packs/pkg/downloader"
+	"github.com/rodcloutier/draft-packs/pkg/draftpath"
+	. "github.com/rodcloutier/draft-packs/pkg/getter"
+)
+
+const verifyDesc = `
+This command verifies the provenance of a pack archive, without installing it.
+
+The pack is either a local archive, whose provenance file must be found next
+to it, or a REPO/NAME reference downloaded, along with its provenance file,
+to a temporary directory.
+
+The signature of the provenance file is checked against the public keys of
+the keyring, and the digest it records against the digest of the archive.
+`
+
+type verifyCmd struct {
+	home    draftpath.Home
+	ref     string
+	version string
+	keyring string
+	output  string
+}
+
+// verifyResult describes a verified pack archive.
+type verifyResult struct {
+	File        string `json:"file"`
+	Hash        string `json:"hash"`
+	SignedBy    string `json:"signedBy"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+func init() {
+	vc := &verifyCmd{}
+
+	cmd := &cobra.Command{
+		Use:   "verify [flags] PATH|REPO/NAME",
+		Short: "verify that a pack archive is signed and intact",
+		Long:  verifyDesc,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("Missing expected argument, the path or the reference of the pack")
+			}
+			if vc.output != "" && vc.output != "json" {
+				return fmt.Errorf("unknown output format %q", vc.output)
+			}
+			vc.ref = args[0]
+			vc.home = draftpath.NewHome(homePath())
+			return vc.run()
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&vc.version, "version", "v", "", "version of the pack to verify, when given a reference. If this is not specified, the latest version is verified")
+	f.StringVar(&vc.keyring, "keyring", defaultKeyring(), "location of public keys used for verification")
+	f.StringVarP(&vc.output, "output", "o", "", "output format, one of: json. Defaults to text")
+
+	RootCmd.AddCommand(cmd)
+}
+
+func (vc *verifyCmd) run() error {
+	ver, err := vc.verify()
+	if err != nil {
+		return err
+	}
+	result := newVerifyResult(ver)
+
+	if vc.output == "json" {
+		b, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+
+	fmt.Printf("Verified %s\n", result.File)
+	fmt.Printf("Signed by: %s\n", result.SignedBy)
+	fmt.Printf("Using key with fingerprint: %s\n", result.Fingerprint)
+	fmt.Printf("Archive hash: %s\n", result.Hash)
+	return nil
+}
+
+// verify verifies the pack archive found at the path vc.ref or, if there is
+// none, the archive downloaded from the repository reference vc.ref.
+func (vc *verifyCmd) verify() (*provenance.Verification, error) {
+	if _, err := os.Stat(vc.ref); err == nil {
+		return downloader.VerifyFile(vc.ref, vc.keyring)
+	}
+
+	tmp, err := ioutil.TempDir("", "draft-packs-verify-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	dl := downloader.Downloader{
+		Home:    vc.home,
+		Out:     os.Stderr,
+		Keyring: vc.keyring,
+		Verify:  downloader.VerifyAlways,
+		Getters: AllProviders(),
+	}
+	_, ver, err := dl.DownloadTo(vc.ref, vc.version, tmp)
+	return ver, err
+}
+
+func newVerifyResult(ver *provenance.Verification) *verifyResult {
+	result := &verifyResult{
+		File: ver.FileName,
+		Hash: ver.FileHash,
+	}
+	if ver.SignedBy != nil {
+		identities := []string{}
+		for name := range ver.SignedBy.Identities {
+			identities = append(identities, name)
+		}
+		sort.Strings(identities)
+		if len(identities) > 0 {
+			result.SignedBy = identities[0]
+		}
+		if ver.SignedBy.PrimaryKey != nil {
+			result.Fingerprint = fmt.Sprintf("%X", ver.SignedBy.PrimaryKey.Fingerprint)
+		}
+	}
+	return result
+}
@@ -0,0 +1,256 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package credentials stores the credentials of the pack repositories outside
+// of the repositories file, either encrypted in $DRAFT_HOME or in an external
+// credential helper.
+package credentials
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultStore is the name of the encrypted store of $DRAFT_HOME.
+const DefaultStore = "encrypted"
+
+const (
+	storeFile = "credentials.enc"
+	keyFile   = "credentials.key"
+
+	// helperPrefix is the prefix of the name of the credential helper
+	// executables.
+	helperPrefix = "draft-credential-"
+	// tokenUsername is the username used by the credential helpers for
+	// identity tokens.
+	tokenUsername = "<token>"
+)
+
+// ErrNotFound indicates that a store has no credentials for a repository.
+var ErrNotFound = errors.New("credentials not found")
+
+// Credentials are the credentials of a repository.
+type Credentials struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Token    string `json:"token,omitempty"`
+}
+
+// Store holds credentials, by repository name.
+type Store interface {
+	Get(name string) (*Credentials, error)
+	Store(name string, c *Credentials) error
+	Erase(name string) error
+}
+
+// NewStore returns the store named name: the encrypted store kept in the
+// directory dir for DefaultStore, or the credential helper executable
+// draft-credential-NAME otherwise.
+func NewStore(dir, name string) Store {
+	if name == DefaultStore {
+		return &fileStore{
+			path:    filepath.Join(dir, storeFile),
+			keyPath: filepath.Join(dir, keyFile),
+		}
+	}
+	return &helperStore{program: helperPrefix + name}
+}
+
+// fileStore keeps the credentials in a file encrypted with AES-GCM, using a
+// key generated on first use.
+type fileStore struct {
+	path    string
+	keyPath string
+}
+
+func (s *fileStore) Get(name string) (*Credentials, error) {
+	all, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	c, ok := all[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return c, nil
+}
+
+func (s *fileStore) Store(name string, c *Credentials) error {
+	all, err := s.load()
+	if err != nil {
+		return err
+	}
+	all[name] = c
+	return s.save(all)
+}
+
+func (s *fileStore) Erase(name string) error {
+	all, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := all[name]; !ok {
+		return nil
+	}
+	delete(all, name)
+	return s.save(all)
+}
+
+func (s *fileStore) load() (map[string]*Credentials, error) {
+	all := map[string]*Credentials{}
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := s.cipher(false)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("%s is corrupted", s.path)
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt %s: %s", s.path, err)
+	}
+	if err := json.Unmarshal(plain, &all); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+func (s *fileStore) save(all map[string]*Credentials) error {
+	plain, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+	gcm, err := s.cipher(true)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path, gcm.Seal(nonce, nonce, plain, nil), 0600)
+}
+
+// cipher returns the cipher of the store, generating its key if create is
+// true and there is none.
+func (s *fileStore) cipher(create bool) (cipher.AEAD, error) {
+	key, err := ioutil.ReadFile(s.keyPath)
+	if os.IsNotExist(err) && create {
+		key = make([]byte, 32)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(s.keyPath), 0755); err != nil {
+			return nil, err
+		}
+		if err := ioutil.WriteFile(s.keyPath, key, 0600); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read the credentials key: %s", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// helperStore delegates to a credential helper following the protocol of the
+// docker credential helpers.
+type helperStore struct {
+	program string
+}
+
+// helperCredentials are the credentials exchanged with a credential helper.
+type helperCredentials struct {
+	ServerURL string
+	Username  string
+	Secret    string
+}
+
+func (s *helperStore) Get(name string) (*Credentials, error) {
+	out, err := s.run("get", name)
+	if err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "credentials not found") {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	hc := &helperCredentials{}
+	if err := json.Unmarshal(out, hc); err != nil {
+		return nil, fmt.Errorf("invalid output of %s: %s", s.program, err)
+	}
+	if hc.Username == tokenUsername {
+		return &Credentials{Token: hc.Secret}, nil
+	}
+	return &Credentials{Username: hc.Username, Password: hc.Secret}, nil
+}
+
+func (s *helperStore) Store(name string, c *Credentials) error {
+	hc := &helperCredentials{ServerURL: name, Username: c.Username, Secret: c.Password}
+	if c.Token != "" {
+		hc.Username = tokenUsername
+		hc.Secret = c.Token
+	}
+	in, err := json.Marshal(hc)
+	if err != nil {
+		return err
+	}
+	_, err = s.run("store", string(in))
+	return err
+}
+
+func (s *helperStore) Erase(name string) error {
+	_, err := s.run("erase", name)
+	return err
+}
+
+// run runs the credential helper command with input on its standard input.
+func (s *helperStore) run(command, input string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(s.program, command)
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stdout.String() + " " + stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("%s %s: %s", s.program, command, msg)
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,142 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credentials
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "draft-packs-credentials-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := NewStore(dir, DefaultStore)
+	if _, err := s.Get("private"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if err := s.Store("private", &Credentials{Username: "user", Password: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Store("other", &Credentials{Token: "t0ken"}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, storeFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("secret")) || bytes.Contains(data, []byte("t0ken")) {
+		t.Error("Expected the credentials to be encrypted")
+	}
+	for _, f := range []string{storeFile, keyFile} {
+		if fi, err := os.Stat(filepath.Join(dir, f)); err != nil {
+			t.Error(err)
+		} else if fi.Mode().Perm() != 0600 {
+			t.Errorf("Expected %s to be private, got %s", f, fi.Mode())
+		}
+	}
+
+	c, err := NewStore(dir, DefaultStore).Get("private")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Username != "user" || c.Password != "secret" {
+		t.Errorf("Unexpected credentials %+v", c)
+	}
+
+	if err := s.Erase("private"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get("private"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound after erase, got %v", err)
+	}
+	if c, err := s.Get("other"); err != nil || c.Token != "t0ken" {
+		t.Errorf("Unexpected credentials %+v, %v", c, err)
+	}
+
+	if err := os.Remove(filepath.Join(dir, keyFile)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get("other"); err == nil {
+		t.Error("Expected an error without key")
+	}
+}
+
+// helper is a credential helper keeping a single secret in a file.
+const helper = `#!/bin/sh
+case "$1" in
+store) cat > "$STORE" ;;
+get) if [ -f "$STORE" ]; then cat "$STORE"; else echo "credentials not found in native keychain"; exit 1; fi ;;
+erase) rm -f "$STORE" ;;
+esac
+`
+
+func TestHelperStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "draft-packs-credentials-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, helperPrefix+"test"), []byte(helper), 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	defer os.Unsetenv("STORE")
+	os.Setenv("STORE", filepath.Join(dir, "store.json"))
+
+	s := NewStore(dir, "test")
+	if _, err := s.Get("private"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if err := s.Store("private", &Credentials{Token: "t0ken"}); err != nil {
+		t.Fatal(err)
+	}
+	c, err := s.Get("private")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Token != "t0ken" || c.Username != "" {
+		t.Errorf("Unexpected credentials %+v", c)
+	}
+
+	if err := s.Store("private", &Credentials{Username: "user", Password: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	if c, err := s.Get("private"); err != nil || c.Username != "user" || c.Password != "secret" {
+		t.Errorf("Unexpected credentials %+v, %v", c, err)
+	}
+
+	if err := s.Erase("private"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get("private"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound after erase, got %v", err)
+	}
+
+	if _, err := NewStore(dir, "missing").Get("private"); err == nil || err == ErrNotFound {
+		t.Errorf("Expected an error for a missing helper, got %v", err)
+	}
+}
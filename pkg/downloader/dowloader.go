@@ -30,6 +30,8 @@ import (
 	//	"k8s.io/helm/pkg/repo"
 	"k8s.io/helm/pkg/urlutil"
 
+	"github.com/rodcloutier/draft-packs/pkg/gitutil"
+	"github.com/rodcloutier/draft-packs/pkg/oci"
 	"github.com/rodcloutier/draft-packs/pkg/repo"
 )
 
@@ -54,6 +56,12 @@ const (
 // ErrNoOwnerRepo indicates that a given chart URL can't be found in any repos.
 var ErrNoOwnerRepo = errors.New("could not find a repo containing the given URL")
 
+// resolver is implemented by the getters of the packs kept in OCI registries,
+// to pin the references of the packs to the digest of their manifest.
+type resolver interface {
+	Resolve(href string) (string, error)
+}
+
 // Home is the interface to fetch repository files and the cache index
 type Home interface {
 	CacheIndex(string) string
@@ -88,7 +96,12 @@ type Downloader struct {
 // Returns a string path to the location where the file was downloaded and a verification
 // (if provenance was verified), or an error if something bad happened.
 func (c *Downloader) DownloadTo(ref, version, dest string) (string, *provenance.Verification, error) {
-	u, g, err := c.ResolveVersion(ref, version)
+	u, g, rc, err := c.resolve(ref, version)
+	if err != nil {
+		return "", nil, err
+	}
+
+	verify, err := c.policy(rc)
 	if err != nil {
 		return "", nil, err
 	}
@@ -99,6 +112,17 @@ func (c *Downloader) DownloadTo(ref, version, dest string) (string, *provenance.
 	}
 
 	name := filepath.Base(u.Path)
+	if gitutil.IsGitURL(u.String()) {
+		// The pack is a directory of a git repository
+		if name, err = gitutil.ArchiveName(u.String()); err != nil {
+			return "", nil, err
+		}
+	} else if u.Scheme == oci.Scheme {
+		// The pack is an artifact of an OCI registry
+		if name, err = oci.ArchiveName(u.String()); err != nil {
+			return "", nil, err
+		}
+	}
 	destfile := filepath.Join(dest, name)
 	if err := ioutil.WriteFile(destfile, data.Bytes(), 0655); err != nil {
 		return destfile, nil, err
@@ -106,10 +130,10 @@ func (c *Downloader) DownloadTo(ref, version, dest string) (string, *provenance.
 
 	// If provenance is requested, verify it.
 	ver := &provenance.Verification{}
-	if c.Verify > VerifyNever {
+	if verify > VerifyNever {
 		body, err := g.Get(u.String() + ".prov")
 		if err != nil {
-			if c.Verify == VerifyAlways {
+			if verify == VerifyAlways {
 				return destfile, ver, fmt.Errorf("Failed to fetch provenance %q", u.String()+".prov")
 			}
 			fmt.Fprintf(c.Out, "WARNING: Verification not found for %s: %s\n", ref, err)
@@ -120,18 +144,50 @@ func (c *Downloader) DownloadTo(ref, version, dest string) (string, *provenance.
 			return destfile, nil, err
 		}
 
-		if c.Verify != VerifyLater {
+		if verify != VerifyLater {
 			ver, err = VerifyFile(destfile, c.Keyring)
 			if err != nil {
 				// Fail always in this case, since it means the verification step
 				// failed.
 				return destfile, ver, err
 			}
+			if rc != nil && ver != nil && ver.SignedBy != nil {
+				fp := fmt.Sprintf("%X", ver.SignedBy.PrimaryKey.Fingerprint)
+				if !rc.Trusts(fp) {
+					return destfile, ver, fmt.Errorf("%s is signed with key %s, which is not trusted by repository %s", ref, fp, rc.Name)
+				}
+			}
 		}
 	}
 	return destfile, ver, nil
 }
 
+// policy returns the verification strategy to use to download a pack of the
+// repository rc, which is nil if the pack comes from no known repository.
+//
+// The strategy is the strictest of Verify and the verification policy of the
+// repository, except for VerifyLater which is left to the caller.
+func (c *Downloader) policy(rc *repo.Entry) (VerificationStrategy, error) {
+	if rc == nil || c.Verify == VerifyLater {
+		return c.Verify, nil
+	}
+
+	verify := VerifyNever
+	switch rc.Verify {
+	case "", repo.VerifyNever:
+	case repo.VerifyIfPossible:
+		verify = VerifyIfPossible
+	case repo.VerifyAlways:
+		verify = VerifyAlways
+	default:
+		return c.Verify, fmt.Errorf("repository %s: %s", rc.Name, repo.ValidateVerify(rc.Verify))
+	}
+	if c.Verify > verify {
+		verify = c.Verify
+	}
+	return verify, nil
+}
+
 // ResolveVersion resolves a chart reference to a URL.
 //
 // It returns the URL as well as a preconfigured repo.Getter that can fetch
@@ -146,18 +202,54 @@ func (c *Downloader) DownloadTo(ref, version, dest string) (string, *provenance.
 //		* If version is non-empty, this will return the URL for that version
 //		* If version is empty, this will return the URL for the latest version
 //		* If no version can be found, an error is returned
+//	- For a reference to a pack of an OCI registry, the version is the tag of
+//	  the pack if it has none, and the returned URL is pinned to the digest of
+//	  the manifest of the pack
 func (c *Downloader) ResolveVersion(ref, version string) (*url.URL, getter.Getter, error) {
+	u, g, _, err := c.resolve(ref, version)
+	return u, g, err
+}
+
+// resolve resolves ref as ResolveVersion does, and also returns the
+// repository of ref, or nil if ref comes from no known repository.
+func (c *Downloader) resolve(ref, version string) (*url.URL, getter.Getter, *repo.Entry, error) {
+	if strings.HasPrefix(ref, oci.Scheme+"://") && version != "" {
+		if r, err := oci.ParseReference(ref); err == nil && r.Tag == "" && r.Digest == "" {
+			r.Tag = oci.TagFromVersion(version)
+			ref = r.String()
+		}
+	}
+
+	u, g, rc, err := c.resolveVersion(ref, version)
+	if err != nil || u.Scheme != oci.Scheme {
+		return u, g, rc, err
+	}
+	r, ok := g.(resolver)
+	if !ok {
+		return u, g, rc, fmt.Errorf("Could not resolve the digest of %s", u)
+	}
+	pinned, err := r.Resolve(u.String())
+	if err != nil {
+		return u, g, rc, err
+	}
+	u, err = url.Parse(pinned)
+	return u, g, rc, err
+}
+
+// resolveVersion resolves ref as resolve does, without pinning the packs of
+// OCI registries to a digest.
+func (c *Downloader) resolveVersion(ref, version string) (*url.URL, getter.Getter, *repo.Entry, error) {
 	u, err := url.Parse(ref)
 	if err != nil {
-		return nil, nil, fmt.Errorf("invalid chart URL format: %s", ref)
+		return nil, nil, nil, fmt.Errorf("invalid chart URL format: %s", ref)
 	}
 
 	rf, err := repo.LoadRepositoriesFile(c.Home.RepositoryFile())
 	if err != nil {
-		return u, nil, err
+		return u, nil, nil, err
 	}
 
-	if u.IsAbs() && len(u.Host) > 0 && len(u.Path) > 0 {
+	if u.IsAbs() && (len(u.Host) > 0 || u.Scheme == "file" || gitutil.IsGitURL(ref)) && len(u.Path) > 0 {
 		// In this case, we have to find the parent repo that contains this chart
 		// URL. And this is an unfortunate problem, as it requires actually going
 		// through each repo cache file and finding a matching URL. But basically
@@ -170,65 +262,65 @@ func (c *Downloader) ResolveVersion(ref, version string) (*url.URL, getter.Gette
 			if err == ErrNoOwnerRepo {
 				getterConstructor, err := c.Getters.ByScheme(u.Scheme)
 				if err != nil {
-					return u, nil, err
+					return u, nil, nil, err
 				}
 				getter, err := getterConstructor(ref, "", "", "")
-				return u, getter, err
+				return u, getter, nil, err
 			}
-			return u, nil, err
+			return u, nil, nil, err
 		}
 		r, err := repo.NewRepository(rc, c.Getters)
 		// If we get here, we don't need to go through the next phase of looking
 		// up the URL. We have it already. So we just return.
-		return u, r.Client, err
+		return u, r.Client, rc, err
 	}
 
 	// See if it's of the form: repo/path_to_chart
 	p := strings.SplitN(u.Path, "/", 2)
 	if len(p) < 2 {
-		return u, nil, fmt.Errorf("Non-absolute URLs should be in form of repo_name/path_to_chart, got: %s", u)
+		return u, nil, nil, fmt.Errorf("Non-absolute URLs should be in form of repo_name/path_to_chart, got: %s", u)
 	}
 
 	repoName := p[0]
 	chartName := p[1]
 	rc, err := pickRepositoryConfigByName(repoName, rf.Repositories)
 	if err != nil {
-		return u, nil, err
+		return u, nil, nil, err
 	}
 
 	r, err := repo.NewRepository(rc, c.Getters)
 	if err != nil {
-		return u, nil, err
+		return u, nil, nil, err
 	}
 
 	// Next, we need to load the index, and actually look up the chart.
 	i, err := repo.LoadIndexFile(c.Home.CacheIndex(r.Config.Name))
 	if err != nil {
-		return u, r.Client, fmt.Errorf("no cached repo found. (try 'helm repo update'). %s", err)
+		return u, r.Client, rc, fmt.Errorf("no cached repo found. (try 'helm repo update'). %s", err)
 	}
 
 	cv, err := i.Get(chartName, version)
 	if err != nil {
-		return u, r.Client, fmt.Errorf("chart %q matching %s not found in %s index. (try 'helm repo update'). %s", chartName, version, r.Config.Name, err)
+		return u, r.Client, rc, fmt.Errorf("chart %q matching %s not found in %s index. (try 'helm repo update'). %s", chartName, version, r.Config.Name, err)
 	}
 
 	if len(cv.URLs) == 0 {
-		return u, r.Client, fmt.Errorf("chart %q has no downloadable URLs", ref)
+		return u, r.Client, rc, fmt.Errorf("chart %q has no downloadable URLs", ref)
 	}
 
 	// TODO: Seems that picking first URL is not fully correct
 	u, err = url.Parse(cv.URLs[0])
 	if err != nil {
-		return u, r.Client, fmt.Errorf("invalid chart URL format: %s", ref)
+		return u, r.Client, rc, fmt.Errorf("invalid chart URL format: %s", ref)
 	}
 
 	// If the URL is relative (no scheme), prepend the chart repo's base URL
 	if !u.IsAbs() {
 		u, err = url.Parse(rc.URL + "/" + u.Path)
-		return u, r.Client, err
+		return u, r.Client, rc, err
 	}
 
-	return u, r.Client, nil
+	return u, r.Client, rc, nil
 }
 
 // VerifyFile takes a path to a archive file and a keyring, and verifies the archive.
@@ -299,6 +391,12 @@ func (c *Downloader) scanReposForURL(u string, rf *repo.RepoFile) (*repo.Entry,
 	// FIXME: This is far from optimal. Larger installations and index files will
 	// incur a performance hit for this type of scanning.
 	for _, rc := range rf.Repositories {
+		// The packs of a registry are not all in its cached index, such as
+		// the ones pinned to a digest
+		if strings.HasPrefix(u, oci.Scheme+"://") && strings.HasPrefix(u, strings.TrimSuffix(rc.URL, "/")+"/") {
+			return rc, nil
+		}
+
 		r, err := repo.NewRepository(rc, c.Getters)
 		if err != nil {
 			return nil, err
@@ -312,6 +410,10 @@ func (c *Downloader) scanReposForURL(u string, rf *repo.RepoFile) (*repo.Entry,
 		for _, entry := range i.Entries {
 			for _, ver := range entry {
 				for _, dl := range ver.URLs {
+					// Relative URLs are relative to the repository
+					if du, err := url.Parse(dl); err == nil && !du.IsAbs() {
+						dl = strings.TrimSuffix(rc.URL, "/") + "/" + dl
+					}
 					if urlutil.Equal(u, dl) {
 						return rc, nil
 					}
@@ -25,18 +25,14 @@ import (
 
 	"github.com/rodcloutier/draft-packs/pkg/draftpath"
 	. "github.com/rodcloutier/draft-packs/pkg/getter"
+	"github.com/rodcloutier/draft-packs/pkg/oci"
+	"github.com/rodcloutier/draft-packs/pkg/oci/ocitest"
 	"github.com/rodcloutier/draft-packs/pkg/repo"
 	"github.com/rodcloutier/draft-packs/pkg/repo/repotest"
 )
 
 func getterAll() (result getter.Providers) {
-	result = getter.Providers{
-		{
-			Schemes: []string{"http", "https"},
-			New:     NewHTTPGetter,
-		},
-	}
-	return result
+	return AllProviders()
 }
 
 func TestResolveRef(t *testing.T) {
@@ -51,7 +47,7 @@ func TestResolveRef(t *testing.T) {
 		{name: "reference, version, testing repo", ref: "testing/alpine", version: "0.2.0", expect: "http://example.com/alpine-0.2.0.tgz"},
 		{name: "reference, version, malformed repo", ref: "malformed/alpine", version: "1.2.3", expect: "http://dl.example.com/alpine-1.2.3.tgz"},
 		{name: "full URL, HTTPS, irrelevant version", ref: "https://example.com/foo-1.2.3.tgz", version: "0.1.0", expect: "https://example.com/foo-1.2.3.tgz", fail: true},
-		{name: "full URL, file", ref: "file:///foo-1.2.3.tgz", fail: true},
+		{name: "full URL, file", ref: "file:///foo-1.2.3.tgz", expect: "file:///foo-1.2.3.tgz"},
 		{name: "invalid", ref: "invalid-1.2.3", fail: true},
 		{name: "not found", ref: "nosuchthing/invalid-1.2.3", fail: true},
 	}
@@ -77,6 +73,117 @@ func TestResolveRef(t *testing.T) {
 	}
 }
 
+func TestResolveFileRepository(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "draft-downloadto-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	hh := draftpath.NewHome(filepath.Join(tmp, "home"))
+	packs := filepath.Join(tmp, "packs")
+	for _, p := range []string{hh.Cache(), packs} {
+		if err := os.MkdirAll(p, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rf := repo.NewRepoFile()
+	rf.Add(&repo.Entry{Name: "local", URL: "file://" + packs, Cache: hh.CacheIndex("local")})
+	if err := rf.WriteFile(hh.RepositoryFile(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	index := "apiVersion: v1\nentries:\n  alpine:\n  - name: alpine\n    version: 0.1.0\n    urls:\n    - alpine-0.1.0.tgz\n"
+	if err := ioutil.WriteFile(filepath.Join(packs, "alpine-0.1.0.tgz"), []byte("archive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(hh.CacheIndex("local"), []byte(index), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := Downloader{
+		Home:    hh,
+		Out:     os.Stderr,
+		Getters: getterAll(),
+	}
+	u, g, err := c.ResolveVersion("local/alpine", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := "file://" + filepath.Join(packs, "alpine-0.1.0.tgz"); u.String() != expect {
+		t.Errorf("Expected %s, got %s", expect, u)
+	}
+	data, err := g.Get(u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.String() != "archive" {
+		t.Errorf("Unexpected content %q", data.String())
+	}
+
+	rc, err := c.scanReposForURL(u.String(), rf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rc.Name != "local" {
+		t.Errorf("Expected repository local, got %s", rc.Name)
+	}
+}
+
+func TestDownloadOCI(t *testing.T) {
+	registry := ocitest.NewRegistry()
+	defer registry.Stop()
+	registry.SetBasicAuth("user", "secret")
+
+	tmp, err := ioutil.TempDir("", "draft-downloadto-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	hh := draftpath.NewHome(tmp)
+	if err := os.MkdirAll(hh.Cache(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	repoURL := "oci://" + registry.Host() + "/packs"
+	rf := repo.NewRepoFile()
+	rf.Add(&repo.Entry{Name: "registry", URL: repoURL, Cache: hh.CacheIndex("registry"), Username: "user", Password: "secret"})
+	if err := rf.WriteFile(hh.RepositoryFile(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	oc := oci.NewClient(nil)
+	oc.Username, oc.Password = "user", "secret"
+	digest, err := oc.Push(&oci.Reference{Registry: registry.Host(), Repository: "packs/alpine", Tag: "0.1.0"}, []byte("{}"), []byte("archive"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := Downloader{
+		Home:    hh,
+		Out:     os.Stderr,
+		Getters: getterAll(),
+	}
+	u, _, err := c.ResolveVersion(repoURL+"/alpine", "0.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := repoURL + "/alpine:0.1.0@" + digest; u.String() != expect {
+		t.Errorf("Expected %s, got %s", expect, u)
+	}
+
+	where, _, err := c.DownloadTo(repoURL+"/alpine:0.1.0", "", tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := filepath.Join(tmp, "alpine-0.1.0.tgz"); where != expect {
+		t.Errorf("Expected download to %s, got %s", expect, where)
+	}
+	if data, err := ioutil.ReadFile(where); err != nil || string(data) != "archive" {
+		t.Errorf("Unexpected archive %q: %v", data, err)
+	}
+}
+
 func TestVerifyFile(t *testing.T) {
 	v, err := VerifyFile("testdata/signtest-0.1.0.tgz", "testdata/helm-test-key.pub")
 	if err != nil {
@@ -263,3 +370,59 @@ func TestScanReposForURL(t *testing.T) {
 		t.Fatalf("expected ErrNoOwnerRepo, got %v", err)
 	}
 }
+
+func TestPolicy(t *testing.T) {
+	c := Downloader{
+		Home:    draftpath.NewHome("testdata/helmhome"),
+		Out:     os.Stderr,
+		Getters: getterAll(),
+	}
+
+	tests := []struct {
+		ref    string
+		verify VerificationStrategy
+		repo   string
+		expect VerificationStrategy
+	}{
+		{"testing/alpine", VerifyNever, "testing", VerifyNever},
+		{"testing/alpine", VerifyAlways, "testing", VerifyAlways},
+		{"testing-https/foo", VerifyNever, "testing-https", VerifyAlways},
+		{"testing-https/foo", VerifyLater, "testing-https", VerifyLater},
+		{"https://no.such.repo/foo-1.2.3.tgz", VerifyIfPossible, "", VerifyIfPossible},
+		{"http://example.com/alpine-0.2.0.tgz", VerifyNever, "testing", VerifyNever},
+	}
+	for _, tt := range tests {
+		c.Verify = tt.verify
+		_, _, rc, err := c.resolve(tt.ref, "")
+		if err != nil {
+			t.Errorf("%s: %s", tt.ref, err)
+			continue
+		}
+		verify, err := c.policy(rc)
+		if err != nil {
+			t.Errorf("%s: %s", tt.ref, err)
+			continue
+		}
+		name := ""
+		if rc != nil {
+			name = rc.Name
+		}
+		if name != tt.repo {
+			t.Errorf("%s: expected repository %q, got %q", tt.ref, tt.repo, name)
+		}
+		if verify != tt.expect {
+			t.Errorf("%s: expected strategy %d, got %d", tt.ref, tt.expect, verify)
+		}
+	}
+
+	_, _, rc, err := c.resolve("testing-https/foo", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rc.Trusts("5E615389B53CA37F0EE60BD3843BBF981FC18762") {
+		t.Error("Expected the pinned key to be trusted")
+	}
+	if rc.Trusts("5E615389B53CA37F0EE60BD3843BBF981FC18763") {
+		t.Error("Expected another key not to be trusted")
+	}
+}
@@ -55,3 +55,25 @@ func (h Home) CacheIndex(name string) string {
 func (h Home) Packs() string {
 	return h.Path("packs")
 }
+
+// Installed returns the path to the directory holding the install records.
+func (h Home) Installed() string {
+	return h.Path("repository", "installed")
+}
+
+// InstalledPack returns the path to the install record of the named pack.
+func (h Home) InstalledPack(name string) string {
+	return h.Path("repository", "installed", name+".yaml")
+}
+
+// Staging returns the path to the directory where packs are prepared before
+// being moved to the packs directory.
+func (h Home) Staging() string {
+	return h.Path("repository", "staging")
+}
+
+// Keyring returns the path to the keyring holding the public keys used to
+// verify packs.
+func (h Home) Keyring() string {
+	return h.Path("repository", "keyring.gpg")
+}
@@ -0,0 +1,39 @@
+package getter
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+
+	helmGetter "k8s.io/helm/pkg/getter"
+)
+
+// FileGetter is the handler of the file URLs of the repositories kept in a
+// local directory, or on a shared mount.
+type FileGetter struct{}
+
+// Get reads the file of the file URL href.
+func (g *FileGetter) Get(href string) (*bytes.Buffer, error) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "file" {
+		return nil, fmt.Errorf("%s is not a file URL", href)
+	}
+	if u.Host != "" && u.Host != "localhost" {
+		return nil, fmt.Errorf("file URL %s must not have a host other than localhost", href)
+	}
+
+	data, err := ioutil.ReadFile(u.Path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to fetch %s : %s", href, err)
+	}
+	return bytes.NewBuffer(data), nil
+}
+
+// NewFileGetter constructs a getter of file URLs. The TLS files are ignored.
+func NewFileGetter(URL, CertFile, KeyFile, CAFile string) (helmGetter.Getter, error) {
+	return &FileGetter{}, nil
+}
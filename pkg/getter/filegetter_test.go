@@ -0,0 +1,44 @@
+package getter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileGetter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "draft-filegetter-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "index.yaml")
+	if err := ioutil.WriteFile(path, []byte(index), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := AllProviders().ByScheme("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fg, err := g("file://"+dir, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := fg.Get("file://" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != index {
+		t.Errorf("Unexpected content %q", buf.String())
+	}
+
+	for _, href := range []string{"file://" + path + ".missing", "file://example.com" + path, "http://example.com/index.yaml"} {
+		if _, err := fg.Get(href); err == nil {
+			t.Errorf("Expected an error for %s", href)
+		}
+	}
+}
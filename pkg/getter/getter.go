@@ -0,0 +1,27 @@
+package getter
+
+import (
+	helmGetter "k8s.io/helm/pkg/getter"
+)
+
+// AllProviders returns the getters of all the supported URL schemes.
+func AllProviders() helmGetter.Providers {
+	return helmGetter.Providers{
+		{
+			Schemes: []string{"http", "https"},
+			New:     NewHTTPGetter,
+		},
+		{
+			Schemes: []string{"file"},
+			New:     NewFileGetter,
+		},
+		{
+			Schemes: []string{"git+http", "git+https", "git+ssh", "git+file"},
+			New:     NewGitGetter,
+		},
+		{
+			Schemes: []string{"oci"},
+			New:     NewOCIGetter,
+		},
+	}
+}
@@ -0,0 +1,64 @@
+package getter
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	helmGetter "k8s.io/helm/pkg/getter"
+
+	"github.com/rodcloutier/draft-packs/pkg/gitutil"
+)
+
+// GitGetter is the handler of the URLs of the packs kept in git
+// repositories. It builds the archive of a pack from the commit of its URL.
+type GitGetter struct {
+	clone string
+}
+
+// SetClone sets the clone of the git repository kept in the cache, used
+// instead of cloning the repository when it has the commit of a pack.
+func (g *GitGetter) SetClone(dir string) {
+	g.clone = dir
+}
+
+// Get returns the archive of the pack URL href, built from the cached clone
+// of its git repository, or from a temporary clone.
+func (g *GitGetter) Get(href string) (*bytes.Buffer, error) {
+	if strings.HasSuffix(href, ".prov") {
+		return nil, fmt.Errorf("Failed to fetch %s : packs of git repositories have no provenance file", href)
+	}
+	remote, commit, p, err := gitutil.ParsePackURL(href)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := g.clone
+	if dir == "" || !gitutil.HasCommit(dir, commit) {
+		tmp, err := ioutil.TempDir("", "draft-packs-git-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(tmp)
+
+		dir = filepath.Join(tmp, "repo.git")
+		if _, err := gitutil.Sync(remote, commit, dir); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := gitutil.Archive(dir, commit, p, gitutil.DirName(remote, p))
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewBuffer(data), nil
+}
+
+// NewGitGetter constructs a getter of the URLs of packs in git repositories.
+// The TLS files are ignored, git uses its own configuration.
+func NewGitGetter(URL, CertFile, KeyFile, CAFile string) (helmGetter.Getter, error) {
+	return &GitGetter{}, nil
+}
@@ -15,16 +15,41 @@ import (
 	helmGetter "k8s.io/helm/pkg/getter"
 )
 
-//httpGetter is the efault HTTP(/S) backend handler
-type httpGetter struct {
-	client *http.Client
+// HTTPGetter is the default HTTP(/S) backend handler
+type HTTPGetter struct {
+	client   *http.Client
+	username string
+	password string
+	token    string
+}
+
+// SetCredentials sets the credentials used for basic authentication.
+func (g *HTTPGetter) SetCredentials(username, password string) {
+	g.username = username
+	g.password = password
+}
+
+// SetToken sets the token sent as a bearer token. It takes precedence over
+// the basic authentication credentials.
+func (g *HTTPGetter) SetToken(token string) {
+	g.token = token
 }
 
 //Get performs a Get from repo.Getter and returns the body.
-func (g *httpGetter) Get(href string) (*bytes.Buffer, error) {
+func (g *HTTPGetter) Get(href string) (*bytes.Buffer, error) {
 	buf := bytes.NewBuffer(nil)
 
-	resp, err := g.client.Get(href)
+	req, err := http.NewRequest("GET", href, nil)
+	if err != nil {
+		return buf, err
+	}
+	if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	} else if g.username != "" || g.password != "" {
+		req.SetBasicAuth(g.username, g.password)
+	}
+
+	resp, err := g.client.Do(req)
 	if err != nil {
 		return buf, err
 	}
@@ -39,7 +64,7 @@ func (g *httpGetter) Get(href string) (*bytes.Buffer, error) {
 
 // newHTTPGetter constructs a valid http/https client as helmGetter
 func NewHTTPGetter(URL, CertFile, KeyFile, CAFile string) (helmGetter.Getter, error) {
-	var client httpGetter
+	var client HTTPGetter
 	if CertFile != "" && KeyFile != "" && CAFile != "" {
 		tlsConf, err := tlsutil.NewClientTLS(CertFile, KeyFile, CAFile)
 		if err != nil {
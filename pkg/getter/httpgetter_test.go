@@ -0,0 +1,92 @@
+package getter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	helmGetter "k8s.io/helm/pkg/getter"
+
+	"github.com/rodcloutier/draft-packs/pkg/repo"
+	"github.com/rodcloutier/draft-packs/pkg/repo/repotest"
+)
+
+const index = `apiVersion: v1
+entries: {}
+`
+
+func newServer(t *testing.T) (*repotest.Server, string) {
+	docroot, err := ioutil.TempDir("", "draft-httpgetter-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := repotest.NewServer(docroot)
+	if err := ioutil.WriteFile(filepath.Join(docroot, "index.yaml"), []byte(index), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return srv, docroot
+}
+
+func TestHTTPGetterBasicAuth(t *testing.T) {
+	srv, docroot := newServer(t)
+	defer os.RemoveAll(docroot)
+	defer srv.Stop()
+	srv.SetBasicAuth("user", "secret")
+
+	g, err := NewHTTPGetter(srv.URL(), "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Get(srv.URL() + "/index.yaml"); err == nil {
+		t.Error("Expected an error without credentials")
+	}
+
+	g.(*HTTPGetter).SetCredentials("user", "wrong")
+	if _, err := g.Get(srv.URL() + "/index.yaml"); err == nil {
+		t.Error("Expected an error with invalid credentials")
+	}
+
+	g.(*HTTPGetter).SetCredentials("user", "secret")
+	buf, err := g.Get(srv.URL() + "/index.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != index {
+		t.Errorf("Unexpected content %q", buf.String())
+	}
+}
+
+func TestHTTPGetterToken(t *testing.T) {
+	srv, docroot := newServer(t)
+	defer os.RemoveAll(docroot)
+	defer srv.Stop()
+	srv.SetToken("t0ken")
+
+	providers := helmGetter.Providers{
+		{
+			Schemes: []string{"http", "https"},
+			New:     NewHTTPGetter,
+		},
+	}
+	cache := filepath.Join(docroot, "cache-index.yaml")
+
+	r, err := repo.NewRepository(&repo.Entry{Name: "test", URL: srv.URL(), Cache: cache}, providers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.DownloadIndexFile(docroot); err == nil {
+		t.Error("Expected an error without token")
+	}
+
+	r, err = repo.NewRepository(&repo.Entry{Name: "test", URL: srv.URL(), Cache: cache, Token: "t0ken"}, providers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.DownloadIndexFile(docroot); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(cache); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,91 @@
+package getter
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	helmGetter "k8s.io/helm/pkg/getter"
+
+	"github.com/rodcloutier/draft-packs/pkg/oci"
+)
+
+// OCIGetter is the handler of the references of the packs kept in OCI
+// registries, such as oci://registry.example.com/packs/golang:1.0.0.
+type OCIGetter struct {
+	client *oci.Client
+}
+
+// SetCredentials sets the credentials used for the authentication to the
+// registry.
+func (g *OCIGetter) SetCredentials(username, password string) {
+	g.client.Username = username
+	g.client.Password = password
+}
+
+// SetToken sets the bearer token sent to the registry.
+func (g *OCIGetter) SetToken(token string) {
+	g.client.Token = token
+}
+
+// Registry returns the client of the registries.
+func (g *OCIGetter) Registry() *oci.Client {
+	return g.client
+}
+
+// Get pulls the archive of the pack href. The provenance file of the pack is
+// returned for href followed by .prov, as for the packs of other repositories.
+func (g *OCIGetter) Get(href string) (*bytes.Buffer, error) {
+	mediaType := oci.ArchiveMediaType
+	if strings.HasSuffix(href, ".prov") {
+		href = strings.TrimSuffix(href, ".prov")
+		mediaType = oci.ProvenanceMediaType
+	}
+
+	ref, err := oci.ParseReference(href)
+	if err != nil {
+		return nil, err
+	}
+	manifest, _, err := g.client.Manifest(ref)
+	if err == oci.ErrNotPack {
+		return nil, fmt.Errorf("Failed to fetch %s : %s", href, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	desc := manifest.Layer(mediaType)
+	if desc == nil {
+		return nil, fmt.Errorf("Failed to fetch %s : no %s layer", href, mediaType)
+	}
+	data, err := g.client.Blob(ref, desc)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewBuffer(data), nil
+}
+
+// Resolve returns the reference href pinned to the digest of its manifest,
+// so that the archive and the provenance file of the pack are fetched from
+// the same manifest even if its tag moves.
+func (g *OCIGetter) Resolve(href string) (string, error) {
+	ref, err := oci.ParseReference(href)
+	if err != nil {
+		return "", err
+	}
+	digest, err := g.client.Resolve(ref)
+	if err != nil {
+		return "", err
+	}
+	ref.Digest = digest
+	return ref.String(), nil
+}
+
+// NewOCIGetter constructs a getter of the packs kept in OCI registries, using
+// the TLS files for the registries served over HTTPS.
+func NewOCIGetter(URL, CertFile, KeyFile, CAFile string) (helmGetter.Getter, error) {
+	g, err := NewHTTPGetter(URL, CertFile, KeyFile, CAFile)
+	if err != nil {
+		return nil, err
+	}
+	return &OCIGetter{client: oci.NewClient(g.(*HTTPGetter).client)}, nil
+}
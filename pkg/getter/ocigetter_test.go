@@ -0,0 +1,58 @@
+package getter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rodcloutier/draft-packs/pkg/oci"
+	"github.com/rodcloutier/draft-packs/pkg/oci/ocitest"
+)
+
+func TestOCIGetter(t *testing.T) {
+	registry := ocitest.NewRegistry()
+	defer registry.Stop()
+	registry.SetBasicAuth("user", "secret")
+
+	href := "oci://" + registry.Host() + "/packs/golang:1.0.0"
+	ref, err := oci.ParseReference(href)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := oci.NewClient(nil)
+	c.Username, c.Password = "user", "secret"
+	digest, err := c.Push(ref, []byte("{}"), []byte("archive"), []byte("provenance"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := AllProviders().ByScheme("oci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	og, err := g(href, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := og.Get(href); err == nil {
+		t.Error("Expected an error without credentials")
+	}
+	og.(*OCIGetter).SetCredentials("user", "secret")
+
+	pinned, err := og.(*OCIGetter).Resolve(href)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(pinned, "@"+digest) {
+		t.Errorf("Expected %s pinned to %s, got %s", href, digest, pinned)
+	}
+
+	for suffix, expect := range map[string]string{"": "archive", ".prov": "provenance"} {
+		buf, err := og.Get(pinned + suffix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != expect {
+			t.Errorf("Expected %q, got %q", expect, buf.String())
+		}
+	}
+}
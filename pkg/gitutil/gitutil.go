@@ -0,0 +1,276 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gitutil runs the git commands behind the pack repositories kept in
+// git repositories.
+//
+// A git repository is given by a git+URL[#REF] URL, such as
+// git+https://example.com/packs.git#v1.0.0 or git+file:///srv/packs.git. The
+// pack found at PATH in a commit is given by git+URL#COMMIT:PATH.
+package gitutil
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Prefix is the prefix of the URLs of git repositories.
+const Prefix = "git+"
+
+// IsGitURL returns whether u is the URL of a git repository, or of a pack in
+// a git repository.
+func IsGitURL(u string) bool {
+	return strings.HasPrefix(u, Prefix)
+}
+
+// ParseURL splits the URL u of a git repository into the URL of the git
+// remote and the ref to use, empty for the default branch.
+func ParseURL(u string) (string, string, error) {
+	if !IsGitURL(u) {
+		return "", "", fmt.Errorf("%s is not a git URL", u)
+	}
+	remote := strings.TrimPrefix(u, Prefix)
+	ref := ""
+	if i := strings.Index(remote, "#"); i != -1 {
+		remote, ref = remote[:i], remote[i+1:]
+	}
+	if remote == "" {
+		return "", "", fmt.Errorf("%s has no git remote", u)
+	}
+	return remote, ref, nil
+}
+
+// PackURL returns the URL of the pack found at p in the commit of the git
+// repository remote.
+func PackURL(remote, commit, p string) string {
+	return Prefix + remote + "#" + commit + ":" + p
+}
+
+// ParsePackURL splits the URL u of a pack into the URL of the git remote, the
+// commit and the path of the pack in the commit.
+func ParsePackURL(u string) (string, string, string, error) {
+	remote, ref, err := ParseURL(u)
+	if err != nil {
+		return "", "", "", err
+	}
+	parts := strings.SplitN(ref, ":", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", "", fmt.Errorf("%s is not the URL of a pack in a git repository", u)
+	}
+	return remote, parts[0], parts[1], nil
+}
+
+// ArchiveName returns the file name of the archive of the pack of the URL u.
+func ArchiveName(u string) (string, error) {
+	remote, commit, p, err := ParsePackURL(u)
+	if err != nil {
+		return "", err
+	}
+	name := DirName(remote, p)
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	return name + "-" + commit + ".tgz", nil
+}
+
+// DirName returns the name of the top-level directory of the archive of the
+// pack found at p in the git repository remote.
+func DirName(remote, p string) string {
+	if p == "" {
+		return strings.TrimSuffix(path.Base(remote), ".git")
+	}
+	return path.Base(p)
+}
+
+// Sync clones the git repository remote in dir, or fetches it if dir is
+// already a clone, and returns the commit of ref, or of the default branch of
+// remote if ref is empty.
+func Sync(remote, ref, dir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, "HEAD")); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return "", err
+		}
+		if _, err := run("", "clone", "--quiet", "--bare", "--", remote, dir); err != nil {
+			return "", err
+		}
+	} else if _, err := run(dir, "fetch", "--quiet", "--prune", "--force", "--tags", "--", remote, "+refs/heads/*:refs/heads/*"); err != nil {
+		return "", err
+	}
+
+	if ref == "" {
+		// The HEAD of the clone is not updated by fetch, the default branch
+		// is the one of the remote.
+		head, err := remoteHead(remote)
+		if err != nil {
+			return "", err
+		}
+		ref = head
+	}
+	out, err := run(dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("ref %q not found in %s", ref, remote)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// remoteHead returns the commit of the default branch of remote.
+func remoteHead(remote string) (string, error) {
+	out, err := run("", "ls-remote", "--symref", "--", remote, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	// ref: refs/heads/main <TAB> HEAD
+	// <commit> <TAB> HEAD
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) == 2 && fields[1] == "HEAD" && !strings.HasPrefix(fields[0], "ref:") {
+			return fields[0], nil
+		}
+	}
+	return "", fmt.Errorf("%s has no default branch", remote)
+}
+
+// HasCommit returns whether the git repository dir has the commit.
+func HasCommit(dir, commit string) bool {
+	if _, err := os.Stat(filepath.Join(dir, "HEAD")); err != nil {
+		return false
+	}
+	_, err := run(dir, "cat-file", "-e", commit+"^{commit}")
+	return err == nil
+}
+
+// ListFiles returns the paths of the files of the commit of the git
+// repository dir.
+func ListFiles(dir, commit string) ([]string, error) {
+	out, err := run(dir, "ls-tree", "-r", "-z", "--name-only", commit)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{}
+	for _, f := range strings.Split(string(out), "\x00") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// ReadFile returns the content of the file p in the commit of the git
+// repository dir.
+func ReadFile(dir, commit, p string) ([]byte, error) {
+	return run(dir, "cat-file", "blob", commit+":"+p)
+}
+
+// Archive returns a gzipped tar archive of the directory p of the commit of
+// the git repository dir, with its files in a top-level directory named name.
+//
+// The archive only depends on the content of the directory, and the time of
+// the commit, so that its digest identifies it.
+func Archive(dir, commit, p, name string) ([]byte, error) {
+	out, err := run(dir, "show", "-s", "--format=%ct", commit)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	mtime := time.Unix(ts, 0)
+
+	treeish := commit
+	if p != "" {
+		treeish = commit + ":" + p
+	}
+	out, err = run(dir, "ls-tree", "-r", "-z", treeish)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, line := range strings.Split(string(out), "\x00") {
+		// <mode> SP <type> SP <object> TAB <file>
+		fields := strings.SplitN(line, "\t", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		meta := strings.Fields(fields[0])
+		if len(meta) != 3 || meta[1] != "blob" {
+			// Submodules are not part of the pack
+			continue
+		}
+		data, err := run(dir, "cat-file", "blob", meta[2])
+		if err != nil {
+			return nil, err
+		}
+
+		h := &tar.Header{
+			Name:    path.Join(name, fields[1]),
+			Mode:    0644,
+			ModTime: mtime,
+		}
+		switch meta[0] {
+		case "120000":
+			h.Typeflag = tar.TypeSymlink
+			h.Linkname = string(data)
+			h.Mode = 0777
+			data = nil
+		case "100755":
+			h.Mode = 0755
+		}
+		h.Size = int64(len(data))
+		if err := tw.WriteHeader(h); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// run runs git with args in the directory dir, and returns its output.
+func run(dir string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	// Fail instead of prompting for credentials
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,176 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitutil
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// git runs a git command in dir, as a test user.
+func git(t *testing.T, dir string, args ...string) string {
+	args = append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %s: %s", args, err, out)
+	}
+	return string(bytes.TrimSpace(out))
+}
+
+// newRepository creates a bare git repository holding files, and returns its
+// path.
+func newRepository(t *testing.T, dir string, files map[string]string) string {
+	work := filepath.Join(dir, "work")
+	for name, content := range files {
+		p := filepath.Join(work, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git(t, work, "init", "--quiet")
+	git(t, work, "add", ".")
+	git(t, work, "commit", "--quiet", "-m", "packs")
+	git(t, work, "tag", "v1")
+
+	bare := filepath.Join(dir, "packs.git")
+	git(t, dir, "clone", "--quiet", "--bare", work, bare)
+	return bare
+}
+
+func TestParseURL(t *testing.T) {
+	remote, ref, err := ParseURL("git+https://example.com/packs.git#v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remote != "https://example.com/packs.git" || ref != "v1.0.0" {
+		t.Errorf("Unexpected remote %q and ref %q", remote, ref)
+	}
+	if _, _, err := ParseURL("https://example.com/packs.git"); err == nil {
+		t.Error("Expected an error for a URL without git+ prefix")
+	}
+
+	u := PackURL("file:///srv/packs.git", "0123456789abcdef", "packs/golang")
+	remote, commit, p, err := ParsePackURL(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remote != "file:///srv/packs.git" || commit != "0123456789abcdef" || p != "packs/golang" {
+		t.Errorf("Unexpected pack URL parts %q %q %q", remote, commit, p)
+	}
+	if name, _ := ArchiveName(u); name != "golang-0123456789ab.tgz" {
+		t.Errorf("Unexpected archive name %s", name)
+	}
+	if _, _, _, err := ParsePackURL("git+file:///srv/packs.git#main"); err == nil {
+		t.Error("Expected an error for a repository URL")
+	}
+}
+
+func TestSyncAndArchive(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "draft-packs-git-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	remote := "file://" + newRepository(t, tmp, map[string]string{
+		"golang/Dockerfile":       "FROM golang",
+		"golang/chart/Chart.yaml": "name: golang\nversion: 0.1.0\n",
+		"README.md":               "packs",
+	})
+
+	dir := filepath.Join(tmp, "cache", "packs")
+	commit, err := Sync(remote, "", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tagged, err := Sync(remote, "v1", dir); err != nil || tagged != commit {
+		t.Errorf("Expected tag v1 to be commit %s, got %s (%v)", commit, tagged, err)
+	}
+	if _, err := Sync(remote, "no-such-ref", dir); err == nil {
+		t.Error("Expected an error for an unknown ref")
+	}
+	if !HasCommit(dir, commit) || HasCommit(dir, "0123456789abcdef0123456789abcdef01234567") {
+		t.Error("Expected the clone to have only the commits of the remote")
+	}
+
+	// The default branch of the remote is followed
+	work := filepath.Join(tmp, "work")
+	git(t, work, "checkout", "--quiet", "-b", "next")
+	git(t, work, "commit", "--quiet", "--allow-empty", "-m", "next")
+	next := git(t, work, "rev-parse", "HEAD")
+	bare := filepath.Join(tmp, "packs.git")
+	git(t, work, "push", "--quiet", bare, "next")
+	git(t, bare, "symbolic-ref", "HEAD", "refs/heads/next")
+	if head, err := Sync(remote, "", dir); err != nil || head != next {
+		t.Errorf("Expected the default branch to be commit %s, got %s (%v)", next, head, err)
+	}
+
+	files, err := ListFiles(dir, commit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 {
+		t.Errorf("Expected 3 files, got %v", files)
+	}
+	if data, err := ReadFile(dir, commit, "golang/Dockerfile"); err != nil || string(data) != "FROM golang" {
+		t.Errorf("Unexpected content %q (%v)", data, err)
+	}
+
+	archive, err := Archive(dir, commit, "golang", "golang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := Archive(dir, commit, "golang", "golang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(archive, again) {
+		t.Error("Expected the archive of a commit to be reproducible")
+	}
+
+	gr, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	names := []string{}
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, h.Name)
+	}
+	sort.Strings(names)
+	if len(names) != 2 || names[0] != "golang/Dockerfile" || names[1] != "golang/chart/Chart.yaml" {
+		t.Errorf("Unexpected archive content %v", names)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package installer installs packs in the packs directory of $DRAFT_HOME.
+//
+// Packs are staged, validated and then moved into place, so that Draft never
+// sees a partially written pack.
+package installer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/draft/pkg/draft/pack"
+
+	"github.com/rodcloutier/draft-packs/pkg/draftpath"
+	"github.com/rodcloutier/draft-packs/pkg/lint"
+)
+
+// Install installs p in the directory dir of the packs directory.
+//
+// The pack is first staged and validated. It is then renamed into place. A
+// pack already installed in dir is kept until the new one is in place, and
+// restored if the swap fails.
+func Install(home draftpath.Home, dir string, p *pack.Pack) error {
+	s, err := Stage(home, dir, p)
+	if err != nil {
+		return err
+	}
+	defer s.Cleanup()
+	return s.Commit()
+}
+
+// Staged is a pack saved and validated in the staging directory of
+// $DRAFT_HOME, ready to be moved into place.
+type Staged struct {
+	// Dest is the directory in which the pack is installed.
+	Dest string
+
+	staged    string
+	backup    string
+	committed bool
+}
+
+// Stage saves p in the staging directory of $DRAFT_HOME and validates it,
+// before its installation in the directory dir of the packs directory.
+func Stage(home draftpath.Home, dir string, p *pack.Pack) (*Staged, error) {
+	if err := os.MkdirAll(home.Staging(), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(home.Packs(), 0755); err != nil {
+		return nil, err
+	}
+
+	staged, err := ioutil.TempDir(home.Staging(), dir+"-")
+	if err != nil {
+		return nil, err
+	}
+	if err := p.SaveDir(staged, true); err != nil {
+		os.RemoveAll(staged)
+		return nil, err
+	}
+	if err := validate(staged); err != nil {
+		os.RemoveAll(staged)
+		return nil, err
+	}
+
+	return &Staged{
+		Dest:   filepath.Join(home.Packs(), dir),
+		staged: staged,
+	}, nil
+}
+
+// Commit moves the staged pack into place.
+//
+// A pack already installed is moved aside and kept until Cleanup is called,
+// so that the commit can be rolled back. It is restored if the staged pack
+// cannot be moved into place.
+func (s *Staged) Commit() error {
+	if _, err := os.Stat(s.Dest); err == nil {
+		backup := s.staged + ".old"
+		if err := os.Rename(s.Dest, backup); err != nil {
+			return err
+		}
+		s.backup = backup
+	}
+	if err := os.Rename(s.staged, s.Dest); err != nil {
+		// Put back the installed pack
+		if rerr := s.restore(); rerr != nil {
+			return fmt.Errorf("%s, and the previous version could not be restored from %s: %s", err, s.backup, rerr)
+		}
+		return err
+	}
+	s.committed = true
+	return nil
+}
+
+// Rollback undoes a commit, restoring the pack previously installed if any.
+func (s *Staged) Rollback() error {
+	if !s.committed {
+		return nil
+	}
+	if err := os.RemoveAll(s.Dest); err != nil {
+		return err
+	}
+	s.committed = false
+	return s.restore()
+}
+
+// Cleanup removes the staged pack, and the previously installed pack once
+// the commit is done. A committed pack can no longer be rolled back.
+func (s *Staged) Cleanup() error {
+	if err := os.RemoveAll(s.staged); err != nil {
+		return err
+	}
+	if !s.committed || s.backup == "" {
+		return nil
+	}
+	if err := os.RemoveAll(s.backup); err != nil {
+		return err
+	}
+	s.backup = ""
+	return nil
+}
+
+func (s *Staged) restore() error {
+	if s.backup == "" {
+		return nil
+	}
+	if err := os.Rename(s.backup, s.Dest); err != nil {
+		return err
+	}
+	s.backup = ""
+	return nil
+}
+
+// validate lints the staged pack, failing on errors only.
+func validate(staged string) error {
+	l, err := lint.Dir(staged)
+	if err != nil {
+		return err
+	}
+	if l.HighestSeverity < lint.ErrorSev {
+		return nil
+	}
+
+	errs := []string{}
+	for _, m := range l.Messages {
+		if m.Severity == lint.ErrorSev {
+			errs = append(errs, m.Error())
+		}
+	}
+	return fmt.Errorf("invalid pack:\n%s", strings.Join(errs, "\n"))
+}
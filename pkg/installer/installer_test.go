@@ -0,0 +1,109 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package installer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "draft-packs-installer-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(d, content string) string {
+		p := filepath.Join(dir, d)
+		if err := os.MkdirAll(p, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(p, "Dockerfile"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	check := func(d, expect string) {
+		b, err := ioutil.ReadFile(filepath.Join(d, "Dockerfile"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != expect {
+			t.Errorf("Expected %q, got %q", expect, string(b))
+		}
+	}
+
+	dest := filepath.Join(dir, "packs", "golang")
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// New install
+	s := &Staged{Dest: dest, staged: write("staged1", "v1")}
+	if err := s.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Cleanup(); err != nil {
+		t.Fatal(err)
+	}
+	check(dest, "v1")
+
+	// Replacement
+	s = &Staged{Dest: dest, staged: write("staged2", "v2")}
+	if err := s.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	check(dest, "v2")
+
+	// Rollback restores the installed pack
+	if err := s.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	check(dest, "v1")
+
+	s = &Staged{Dest: dest, staged: write("staged3", "v3")}
+	if err := s.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Cleanup(); err != nil {
+		t.Fatal(err)
+	}
+	check(dest, "v3")
+	if _, err := os.Stat(s.staged + ".old"); !os.IsNotExist(err) {
+		t.Error("Expected the previous version to be removed")
+	}
+
+	// Failed replacement restores the installed pack
+	s = &Staged{Dest: dest, staged: filepath.Join(dir, "missing")}
+	if err := s.Commit(); err == nil {
+		t.Error("Expected an error for a missing staged pack")
+	}
+	check(dest, "v3")
+}
+
+func TestValidate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "draft-packs-installer-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := validate(dir); err == nil {
+		t.Error("Expected an empty pack to be invalid")
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package keyring manages the keyring of public keys used to verify the
+// provenance of packs.
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+// ErrKeyNotFound indicates that no key of the keyring matches a key ID.
+var ErrKeyNotFound = errors.New("no matching key found in keyring")
+
+// Keyring is a list of public keys, stored as a GnuPG public keyring.
+type Keyring struct {
+	Entities openpgp.EntityList
+}
+
+// LoadKeyring reads the keyring at path. A missing keyring is empty.
+func LoadKeyring(path string) (*Keyring, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return &Keyring{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entities, err := openpgp.ReadKeyRing(f)
+	if err != nil {
+		return nil, fmt.Errorf("could not read keyring %s: %s", path, err)
+	}
+	return &Keyring{Entities: entities}, nil
+}
+
+// ReadKeys reads the keys of data, which is either ASCII armored or binary.
+func ReadKeys(data []byte) (openpgp.EntityList, error) {
+	if entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data)); err == nil {
+		return entities, nil
+	}
+	entities, err := openpgp.ReadKeyRing(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("no valid key found: %s", err)
+	}
+	return entities, nil
+}
+
+// Import adds the keys of entities to the keyring, replacing the keys with
+// the same fingerprint.
+func (k *Keyring) Import(entities openpgp.EntityList) {
+	for _, e := range entities {
+		replaced := false
+		for i, existing := range k.Entities {
+			if Fingerprint(existing) == Fingerprint(e) {
+				k.Entities[i] = e
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			k.Entities = append(k.Entities, e)
+		}
+	}
+}
+
+// Find returns the keys matching id, which is a fingerprint, a long or short
+// key ID, or a part of a user identity.
+func (k *Keyring) Find(id string) openpgp.EntityList {
+	found := openpgp.EntityList{}
+	for _, e := range k.Entities {
+		if matches(e, id) {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+// Remove removes the keys matching id from the keyring and returns them.
+func (k *Keyring) Remove(id string) (openpgp.EntityList, error) {
+	removed := openpgp.EntityList{}
+	kept := openpgp.EntityList{}
+	for _, e := range k.Entities {
+		if matches(e, id) {
+			removed = append(removed, e)
+		} else {
+			kept = append(kept, e)
+		}
+	}
+	if len(removed) == 0 {
+		return nil, ErrKeyNotFound
+	}
+	k.Entities = kept
+	return removed, nil
+}
+
+// WriteFile writes the public part of the keys of the keyring to path.
+func (k *Keyring) WriteFile(path string, perm os.FileMode) error {
+	var buf bytes.Buffer
+	for _, e := range k.Entities {
+		if err := e.Serialize(&buf); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), perm)
+}
+
+// Export writes the public part of entities to w, ASCII armored.
+func Export(w io.Writer, entities openpgp.EntityList) error {
+	a, err := armor.Encode(w, openpgp.PublicKeyType, nil)
+	if err != nil {
+		return err
+	}
+	for _, e := range entities {
+		if err := e.Serialize(a); err != nil {
+			return err
+		}
+	}
+	return a.Close()
+}
+
+// Fingerprint returns the fingerprint of the primary key of e, in
+// hexadecimal.
+func Fingerprint(e *openpgp.Entity) string {
+	return fmt.Sprintf("%X", e.PrimaryKey.Fingerprint)
+}
+
+// Identities returns the user identities of e, sorted.
+func Identities(e *openpgp.Entity) []string {
+	identities := []string{}
+	for name := range e.Identities {
+		identities = append(identities, name)
+	}
+	sort.Strings(identities)
+	return identities
+}
+
+func matches(e *openpgp.Entity, id string) bool {
+	fp := Fingerprint(e)
+	hex := strings.ToUpper(strings.TrimPrefix(strings.Replace(id, " ", "", -1), "0x"))
+	if len(hex) >= 8 && strings.HasSuffix(fp, hex) {
+		return true
+	}
+	for _, name := range Identities(e) {
+		if strings.Contains(strings.ToLower(name), strings.ToLower(id)) {
+			return true
+		}
+	}
+	return false
+}
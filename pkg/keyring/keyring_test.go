@@ -0,0 +1,105 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyring
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+func newEntity(t *testing.T, name, email string) *openpgp.Entity {
+	e, err := openpgp.NewEntity(name, "", email, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestKeyring(t *testing.T) {
+	dir, err := ioutil.TempDir("", "draft-packs-keyring-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "repository", "keyring.gpg")
+
+	k, err := LoadKeyring(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(k.Entities) != 0 {
+		t.Fatalf("Expected an empty keyring, got %d keys", len(k.Entities))
+	}
+
+	alice := newEntity(t, "Alice", "alice@example.com")
+	bob := newEntity(t, "Bob", "bob@example.com")
+
+	var armored bytes.Buffer
+	if err := Export(&armored, openpgp.EntityList{alice, bob}); err != nil {
+		t.Fatal(err)
+	}
+	entities, err := ReadKeys(armored.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.Import(entities)
+	k.Import(openpgp.EntityList{alice})
+	if len(k.Entities) != 2 {
+		t.Fatalf("Expected 2 keys, got %d", len(k.Entities))
+	}
+
+	if err := k.WriteFile(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+	k, err = LoadKeyring(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(k.Entities) != 2 {
+		t.Fatalf("Expected 2 keys after reload, got %d", len(k.Entities))
+	}
+
+	fp := Fingerprint(alice)
+	for _, id := range []string{fp, fp[len(fp)-16:], "0x" + fp[len(fp)-8:], "alice@EXAMPLE"} {
+		if found := k.Find(id); len(found) != 1 || Fingerprint(found[0]) != fp {
+			t.Errorf("Expected %q to find the key of Alice, got %d keys", id, len(found))
+		}
+	}
+
+	removed, err := k.Remove("bob@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || Fingerprint(removed[0]) != Fingerprint(bob) {
+		t.Errorf("Expected the key of Bob to be removed")
+	}
+	if _, err := k.Remove("bob@example.com"); err != ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
+	if ids := Identities(k.Entities[0]); len(ids) != 1 || ids[0] != "Alice <alice@example.com>" {
+		t.Errorf("Unexpected identities %v", ids)
+	}
+}
+
+func TestReadKeysInvalid(t *testing.T) {
+	if _, err := ReadKeys([]byte("not a key")); err == nil {
+		t.Error("Expected an error for invalid keys")
+	}
+}
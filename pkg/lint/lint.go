@@ -0,0 +1,227 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lint provides validation of packs before they are packaged or used.
+//
+// The linter walks a pack directory (or an archived pack) and reports the
+// problems found as messages. Errors are problems that will prevent Draft from
+// using the pack, warnings are problems that break with convention.
+package lint
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Severity indicates the severity of a Message.
+type Severity int
+
+const (
+	// UnknownSev indicates that the severity of the message is unknown.
+	UnknownSev Severity = iota
+	// WarningSev indicates that something does not meet the pack conventions.
+	WarningSev
+	// ErrorSev indicates that something will prevent the pack from being used.
+	ErrorSev
+)
+
+var sevNames = []string{"UNKNOWN", "WARNING", "ERROR"}
+
+// String returns the name of the severity.
+func (s Severity) String() string {
+	if int(s) < 0 || int(s) >= len(sevNames) {
+		return sevNames[UnknownSev]
+	}
+	return sevNames[s]
+}
+
+// Message describes a single problem found in a pack.
+type Message struct {
+	// Severity is one of the *Sev constants.
+	Severity Severity
+	// Path is the path of the offending file, relative to the pack root.
+	Path string
+	// Line is the line of the problem in the file, 0 if it does not apply.
+	Line int
+	// Err is the problem found.
+	Err error
+}
+
+// Error formats the message as "[SEVERITY] path:line: error".
+func (m Message) Error() string {
+	location := m.Path
+	if m.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, m.Line)
+	}
+	return fmt.Sprintf("[%s] %s: %s", m.Severity, location, m.Err)
+}
+
+// Linter accumulates the messages reported while linting a pack.
+type Linter struct {
+	// Messages are the problems found, in the order they were found.
+	Messages []Message
+	// HighestSeverity is the highest severity of all the messages.
+	HighestSeverity Severity
+	// PackDir is the directory of the pack being linted.
+	PackDir string
+}
+
+// report adds a message to the linter.
+func (l *Linter) report(sev Severity, path string, line int, err error) {
+	l.Messages = append(l.Messages, Message{Severity: sev, Path: path, Line: line, Err: err})
+	if sev > l.HighestSeverity {
+		l.HighestSeverity = sev
+	}
+}
+
+func (l *Linter) errorf(path string, line int, format string, a ...interface{}) {
+	l.report(ErrorSev, path, line, fmt.Errorf(format, a...))
+}
+
+func (l *Linter) warnf(path string, line int, format string, a ...interface{}) {
+	l.report(WarningSev, path, line, fmt.Errorf(format, a...))
+}
+
+// Path lints the pack found at path, which can be either a pack directory or
+// a pack archive (.tgz).
+func Path(path string) (*Linter, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return Dir(path)
+	}
+	if strings.ToLower(filepath.Ext(path)) != ".tgz" {
+		return nil, fmt.Errorf("%s is neither a pack directory nor a pack archive (.tgz)", path)
+	}
+	return Archive(path)
+}
+
+// Dir lints the pack directory dir.
+func Dir(dir string) (*Linter, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(abs)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	l := &Linter{PackDir: abs}
+	for _, rule := range rules {
+		rule(l)
+	}
+	return l, nil
+}
+
+// Archive lints the pack contained in the archive file path.
+//
+// The archive is expanded in a temporary directory, preserving the file modes
+// recorded in the archive.
+func Archive(path string) (*Linter, error) {
+	tmp, err := ioutil.TempDir("", "draft-packs-lint-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	top, err := expand(tmp, f)
+	if err != nil {
+		return nil, fmt.Errorf("could not expand %s: %s", path, err)
+	}
+
+	l, err := Dir(filepath.Join(tmp, top))
+	if err != nil {
+		return nil, err
+	}
+	// The temporary directory does not outlive this call.
+	l.PackDir = path
+	return l, nil
+}
+
+// expand extracts a gzipped tar archive into dir and returns the name of the
+// top level directory of the archive.
+func expand(dir string, r io.Reader) (string, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return "", err
+	}
+	defer gr.Close()
+
+	top := ""
+	tr := tar.NewReader(gr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		name := filepath.Clean(filepath.FromSlash(h.Name))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("illegal file path in archive: %s", h.Name)
+		}
+		parts := strings.SplitN(name, string(filepath.Separator), 2)
+		if top == "" {
+			top = parts[0]
+		} else if top != parts[0] {
+			return "", errors.New("archive must contain a single top level directory")
+		}
+
+		dest := filepath.Join(dir, name)
+		switch h.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(dest, 0755); err != nil {
+				return "", err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+				return "", err
+			}
+			out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(h.Mode).Perm())
+			if err != nil {
+				return "", err
+			}
+			_, err = io.Copy(out, tr)
+			out.Close()
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+	if top == "" {
+		return "", errors.New("archive is empty")
+	}
+	return top, nil
+}
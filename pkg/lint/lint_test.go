@@ -0,0 +1,130 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var goodPack = map[string]string{
+	"Pack.yaml":                       "Name: mypack\nVersion: 0.1.0\n",
+	"Dockerfile":                      "FROM golang:onbuild\n",
+	"detect":                          "#!/bin/sh\nexit 1\n",
+	"chart/Chart.yaml":                "name: mypack\n",
+	"chart/values.yaml":               "replicaCount: 1\n",
+	"chart/templates/deployment.yaml": "kind: Deployment\n",
+}
+
+func writePack(t *testing.T, files map[string]string) string {
+	tmp, err := ioutil.TempDir("", "draft-packs-lint-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(tmp, "mypack")
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		mode := os.FileMode(0644)
+		if name == DetectFile {
+			mode = 0755
+		}
+		if err := ioutil.WriteFile(p, []byte(content), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestDirGood(t *testing.T) {
+	dir := writePack(t, goodPack)
+	defer os.RemoveAll(filepath.Dir(dir))
+
+	l, err := Dir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Messages) != 0 {
+		t.Errorf("Expected no messages, got %v", l.Messages)
+	}
+}
+
+func TestDirBad(t *testing.T) {
+	files := map[string]string{
+		"Pack.yaml":                  "Name: mypack\nVersion: one\n",
+		"chart/Chart.yaml":           "name: other\n",
+		"chart/values.yaml":          "replicaCount: 1\n\tbad: tab\n",
+		"chart/templates/empty.yaml": "\n",
+	}
+	dir := writePack(t, files)
+	defer os.RemoveAll(filepath.Dir(dir))
+
+	l, err := Dir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.HighestSeverity != ErrorSev {
+		t.Errorf("Expected highest severity to be %s, got %s", ErrorSev, l.HighestSeverity)
+	}
+
+	expect := []string{
+		"[ERROR] detect: detect script is missing",
+		"[ERROR] Dockerfile: Dockerfile is missing",
+		"[ERROR] Pack.yaml:2: version \"one\" is not a valid SemVer",
+		"[WARNING] chart/Chart.yaml:1: name \"other\" does not match the pack directory \"mypack\"",
+		"[ERROR] chart/values.yaml:2: unable to parse values.yaml",
+		"[WARNING] chart/templates/empty.yaml: template is empty",
+	}
+	if len(l.Messages) != len(expect) {
+		t.Fatalf("Expected %d messages, got %d: %v", len(expect), len(l.Messages), l.Messages)
+	}
+	for i, e := range expect {
+		if got := l.Messages[i].Error(); !strings.HasPrefix(got, e) {
+			t.Errorf("Expected message %d to start with %q, got %q", i, e, got)
+		}
+	}
+}
+
+func TestDirNotExecutable(t *testing.T) {
+	dir := writePack(t, goodPack)
+	defer os.RemoveAll(filepath.Dir(dir))
+
+	if err := os.Chmod(filepath.Join(dir, DetectFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := Dir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Messages) != 1 || l.Messages[0].Severity != ErrorSev || l.Messages[0].Path != DetectFile {
+		t.Errorf("Expected a single detect error, got %v", l.Messages)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	l, err := Path("../repo/repotest/testdata/examplepack.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.HighestSeverity == ErrorSev {
+		t.Errorf("Expected no errors, got %v", l.Messages)
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/ghodss/yaml"
+
+	"github.com/Azure/draft/pkg/draft/pack"
+	"k8s.io/helm/pkg/proto/hapi/chart"
+
+	"github.com/rodcloutier/draft-packs/pkg/packutil"
+)
+
+const (
+	// MetadataFile is the name of the pack metadata file.
+	MetadataFile = "Pack.yaml"
+	// DetectFile is the name of the detection script.
+	DetectFile = "detect"
+	// DockerFile is the name of the Dockerfile.
+	DockerFile = "Dockerfile"
+	// ChartDir is the name of the directory holding the chart.
+	ChartDir = "chart"
+)
+
+var (
+	chartfilePath = filepath.Join(ChartDir, "Chart.yaml")
+	valuesPath    = filepath.Join(ChartDir, "values.yaml")
+	templatesPath = filepath.Join(ChartDir, "templates")
+)
+
+// rules are run in order against the pack being linted.
+var rules = []func(*Linter){
+	lintDetect,
+	lintDockerfile,
+	lintMetadata,
+	lintChartfile,
+	lintValues,
+	lintTemplates,
+}
+
+func lintDetect(l *Linter) {
+	fi, err := os.Stat(filepath.Join(l.PackDir, DetectFile))
+	if err != nil {
+		l.errorf(DetectFile, 0, "detect script is missing")
+		return
+	}
+	if fi.IsDir() {
+		l.errorf(DetectFile, 0, "detect script is a directory")
+		return
+	}
+	if fi.Mode().Perm()&0111 == 0 {
+		l.errorf(DetectFile, 0, "detect script is not executable")
+	}
+	if fi.Size() == 0 {
+		l.errorf(DetectFile, 0, "detect script is empty")
+	}
+}
+
+func lintDockerfile(l *Linter) {
+	data, err := ioutil.ReadFile(filepath.Join(l.PackDir, DockerFile))
+	if err != nil {
+		l.errorf(DockerFile, 0, "Dockerfile is missing")
+		return
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		l.warnf(DockerFile, 0, "Dockerfile is empty")
+	}
+}
+
+func lintMetadata(l *Linter) {
+	data, err := ioutil.ReadFile(filepath.Join(l.PackDir, MetadataFile))
+	if err != nil {
+		l.warnf(MetadataFile, 0, "pack metadata is missing, name and version will not be available to repositories")
+		return
+	}
+
+	md := &pack.Metadata{}
+	if err := yaml.Unmarshal(data, md); err != nil {
+		l.errorf(MetadataFile, errorLine(err), "unable to parse pack metadata: %s", err)
+		return
+	}
+
+	if md.Name == "" {
+		l.errorf(MetadataFile, 0, "name is required")
+	} else if dir := filepath.Base(l.PackDir); md.Name != dir {
+		l.warnf(MetadataFile, keyLine(data, "name"), "name %q does not match the pack directory %q", md.Name, dir)
+	}
+
+	if md.Version == "" {
+		l.errorf(MetadataFile, 0, "version is required")
+	} else if _, err := semver.NewVersion(md.Version); err != nil {
+		l.errorf(MetadataFile, keyLine(data, "version"), "version %q is not a valid SemVer", md.Version)
+	}
+
+	if _, err := packutil.ParseDependencies(data); err != nil {
+		l.errorf(MetadataFile, keyLine(data, "dependencies"), "%s", err)
+	}
+}
+
+func lintChartfile(l *Linter) {
+	if fi, err := os.Stat(filepath.Join(l.PackDir, ChartDir)); err != nil || !fi.IsDir() {
+		l.errorf(ChartDir, 0, "chart directory is missing")
+		return
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(l.PackDir, chartfilePath))
+	if err != nil {
+		l.errorf(chartfilePath, 0, "Chart.yaml is missing")
+		return
+	}
+
+	md := &chart.Metadata{}
+	if err := yaml.Unmarshal(data, md); err != nil {
+		l.errorf(chartfilePath, errorLine(err), "unable to parse Chart.yaml: %s", err)
+		return
+	}
+
+	if md.Name == "" {
+		l.errorf(chartfilePath, 0, "name is required")
+	} else if dir := filepath.Base(l.PackDir); md.Name != dir {
+		l.warnf(chartfilePath, keyLine(data, "name"), "name %q does not match the pack directory %q", md.Name, dir)
+	}
+
+	// Draft versions the chart when it is deployed, a version is not required.
+	if md.Version != "" {
+		if _, err := semver.NewVersion(md.Version); err != nil {
+			l.errorf(chartfilePath, keyLine(data, "version"), "version %q is not a valid SemVer", md.Version)
+		}
+	}
+}
+
+func lintValues(l *Linter) {
+	data, err := ioutil.ReadFile(filepath.Join(l.PackDir, valuesPath))
+	if err != nil {
+		l.warnf(valuesPath, 0, "values.yaml is missing")
+		return
+	}
+
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		l.errorf(valuesPath, errorLine(err), "unable to parse values.yaml: %s", err)
+	}
+}
+
+func lintTemplates(l *Linter) {
+	files, err := ioutil.ReadDir(filepath.Join(l.PackDir, templatesPath))
+	if err != nil {
+		l.warnf(templatesPath, 0, "templates directory is missing")
+		return
+	}
+
+	found := false
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		found = true
+
+		path := filepath.Join(templatesPath, f.Name())
+		data, err := ioutil.ReadFile(filepath.Join(l.PackDir, path))
+		if err != nil {
+			l.errorf(path, 0, "unable to read template: %s", err)
+			continue
+		}
+		if len(bytes.TrimSpace(data)) == 0 {
+			l.warnf(path, 0, "template is empty")
+		}
+	}
+	if !found {
+		l.warnf(templatesPath, 0, "chart has no templates")
+	}
+}
+
+var yamlLineRe = regexp.MustCompile(`line (\d+)`)
+
+// errorLine extracts the line number from a YAML parsing error, 0 if none.
+func errorLine(err error) int {
+	m := yamlLineRe.FindStringSubmatch(err.Error())
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+// keyLine returns the line of the top level key in data, 0 if not found.
+//
+// Keys are compared case insensitively since the pack metadata is written
+// with capitalized keys.
+func keyLine(data []byte, key string) int {
+	prefix := strings.ToLower(key) + ":"
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; s.Scan(); n++ {
+		if strings.HasPrefix(strings.ToLower(s.Text()), prefix) {
+			return n
+		}
+	}
+	return 0
+}
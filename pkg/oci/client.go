@@ -0,0 +1,506 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrNotPack indicates that a manifest of a registry is not the one of a pack,
+// such as the manifest of an image.
+var ErrNotPack = errors.New("not a pack")
+
+// manifestMediaTypes are the media types of the manifests accepted from the
+// registries, so that the manifests of images are recognized as such.
+var manifestMediaTypes = []string{
+	ManifestMediaType,
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+}
+
+// Client is a client of the distribution API of OCI registries.
+type Client struct {
+	// Username and Password are the credentials for basic authentication,
+	// also used to request bearer tokens from the registry token service.
+	Username string
+	Password string
+	// Token is a bearer token sent to the registry. It takes precedence over
+	// the basic authentication credentials.
+	Token string
+	// PlainHTTP is whether the registry is served over HTTP rather than
+	// HTTPS. Registries of the local machine always are.
+	PlainHTTP bool
+
+	client *http.Client
+
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+// NewClient returns a client of OCI registries sending its requests with
+// client, or with the default HTTP client if nil.
+func NewClient(client *http.Client) *Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Client{client: client, tokens: map[string]string{}}
+}
+
+// Pack is a pack pulled from a registry.
+type Pack struct {
+	// Digest is the digest of the manifest of the pack.
+	Digest   string
+	Manifest *Manifest
+	// Config is the metadata of the pack, in JSON.
+	Config []byte
+	// Archive is the pack archive.
+	Archive []byte
+	// Provenance is the provenance file of the pack, nil if unsigned.
+	Provenance []byte
+}
+
+// Push pushes the pack archive with its metadata config, in JSON, and its
+// provenance file prov, nil if unsigned, to ref. It returns the digest of
+// the manifest of the pack.
+func (c *Client) Push(ref *Reference, config, archive, prov []byte) (string, error) {
+	if ref.Tag == "" {
+		return "", fmt.Errorf("missing the tag of %s", ref)
+	}
+	manifest := &Manifest{SchemaVersion: 2, MediaType: ManifestMediaType}
+
+	desc, err := c.pushBlob(ref, ConfigMediaType, config)
+	if err != nil {
+		return "", err
+	}
+	manifest.Config = *desc
+
+	desc, err = c.pushBlob(ref, ArchiveMediaType, archive)
+	if err != nil {
+		return "", err
+	}
+	manifest.Layers = append(manifest.Layers, *desc)
+
+	if prov != nil {
+		desc, err = c.pushBlob(ref, ProvenanceMediaType, prov)
+		if err != nil {
+			return "", err
+		}
+		manifest.Layers = append(manifest.Layers, *desc)
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return "", err
+	}
+	header := http.Header{"Content-Type": {ManifestMediaType}}
+	resp, err := c.do("PUT", c.url(ref, "manifests/"+ref.Tag), header, data)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("could not push the manifest of %s: %s", ref, resp.Status)
+	}
+	return Digest(data), nil
+}
+
+// pushBlob uploads data to the repository of ref, unless the registry
+// already has it, and returns its descriptor.
+func (c *Client) pushBlob(ref *Reference, mediaType string, data []byte) (*Descriptor, error) {
+	desc := &Descriptor{MediaType: mediaType, Digest: Digest(data), Size: int64(len(data))}
+
+	resp, err := c.do("HEAD", c.url(ref, "blobs/"+desc.Digest), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return desc, nil
+	}
+
+	resp, err = c.do("POST", c.url(ref, "blobs/uploads/"), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return nil, fmt.Errorf("could not start the upload of %s to %s: %s", desc.Digest, ref, resp.Status)
+	}
+	location, err := resp.Request.URL.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid upload location from %s: %s", ref.Registry, err)
+	}
+	q := location.Query()
+	q.Set("digest", desc.Digest)
+	location.RawQuery = q.Encode()
+
+	header := http.Header{"Content-Type": {"application/octet-stream"}}
+	resp, err = c.do("PUT", location.String(), header, data)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("could not upload %s to %s: %s", desc.Digest, ref, resp.Status)
+	}
+	return desc, nil
+}
+
+// Resolve returns the digest of the manifest of ref.
+func (c *Client) Resolve(ref *Reference) (string, error) {
+	if ref.Digest != "" {
+		return ref.Digest, nil
+	}
+	header := http.Header{"Accept": {ManifestMediaType}}
+	resp, err := c.do("HEAD", c.url(ref, "manifests/"+ref.manifestReference()), header, nil)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("could not resolve %s: %s", ref, resp.Status)
+	}
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if !digestPattern.MatchString(digest) {
+		// The digest header is optional, fall back to hashing the manifest.
+		_, digest, err = c.Manifest(ref)
+		return digest, err
+	}
+	return digest, nil
+}
+
+// Manifest fetches the manifest of the pack ref and returns it with its
+// digest. It returns ErrNotPack if ref is not a pack.
+func (c *Client) Manifest(ref *Reference) (*Manifest, string, error) {
+	header := http.Header{"Accept": {strings.Join(manifestMediaTypes, ", ")}}
+	data, _, err := c.get(c.url(ref, "manifests/"+ref.manifestReference()), header)
+	if err != nil {
+		return nil, "", err
+	}
+	digest := Digest(data)
+	if ref.Digest != "" && ref.Digest != digest {
+		return nil, "", fmt.Errorf("manifest of %s does not match its digest", ref)
+	}
+
+	manifest := &Manifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, "", fmt.Errorf("invalid manifest for %s: %s", ref, err)
+	}
+	if (manifest.MediaType != "" && manifest.MediaType != ManifestMediaType) || manifest.Config.MediaType != ConfigMediaType || manifest.Layer(ArchiveMediaType) == nil {
+		return nil, "", ErrNotPack
+	}
+	return manifest, digest, nil
+}
+
+// Blob fetches the blob desc of the repository of ref and checks its digest.
+func (c *Client) Blob(ref *Reference, desc *Descriptor) ([]byte, error) {
+	data, _, err := c.get(c.url(ref, "blobs/"+desc.Digest), nil)
+	if err != nil {
+		return nil, err
+	}
+	if Digest(data) != desc.Digest {
+		return nil, fmt.Errorf("blob %s of %s does not match its digest", desc.Digest, ref)
+	}
+	return data, nil
+}
+
+// Pull fetches the pack ref.
+func (c *Client) Pull(ref *Reference) (*Pack, error) {
+	manifest, digest, err := c.Manifest(ref)
+	if err == ErrNotPack {
+		return nil, fmt.Errorf("%s is %s", ref, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	p := &Pack{Digest: digest, Manifest: manifest}
+
+	if p.Config, err = c.Blob(ref, &manifest.Config); err != nil {
+		return nil, err
+	}
+	if p.Archive, err = c.Blob(ref, manifest.Layer(ArchiveMediaType)); err != nil {
+		return nil, err
+	}
+	if desc := manifest.Layer(ProvenanceMediaType); desc != nil {
+		if p.Provenance, err = c.Blob(ref, desc); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Tags returns the tags of the repository of ref, sorted.
+func (c *Client) Tags(ref *Reference) ([]string, error) {
+	var tags []string
+	err := c.list(c.url(ref, "tags/list"), func(data []byte) error {
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return fmt.Errorf("invalid tag list for %s: %s", ref, err)
+		}
+		tags = append(tags, page.Tags...)
+		return nil
+	})
+	sort.Strings(tags)
+	return tags, err
+}
+
+// Catalog returns the repositories of the registry, sorted. Registries may
+// restrict, or not support, the listing of their repositories.
+func (c *Client) Catalog(registry string) ([]string, error) {
+	var repositories []string
+	err := c.list(c.baseURL(registry)+"/v2/_catalog", func(data []byte) error {
+		var page struct {
+			Repositories []string `json:"repositories"`
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return fmt.Errorf("invalid catalog for %s: %s", registry, err)
+		}
+		repositories = append(repositories, page.Repositories...)
+		return nil
+	})
+	sort.Strings(repositories)
+	return repositories, err
+}
+
+// list fetches the pages of the list at u, following the links to their
+// next page, and decodes them with decode.
+func (c *Client) list(u string, decode func([]byte) error) error {
+	for u != "" {
+		data, resp, err := c.get(u, nil)
+		if err != nil {
+			return err
+		}
+		if err := decode(data); err != nil {
+			return err
+		}
+		u, err = nextPage(resp)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nextPage returns the URL of the next page of a list, given by the Link
+// header of resp such as </v2/_catalog?last=golang&n=100>; rel="next", or an
+// empty string for the last page.
+func nextPage(resp *http.Response) (string, error) {
+	for _, link := range resp.Header["Link"] {
+		for _, l := range strings.Split(link, ",") {
+			parts := strings.Split(l, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range parts[1:] {
+				if strings.Replace(strings.TrimSpace(param), " ", "", -1) != `rel="next"` {
+					continue
+				}
+				next, err := resp.Request.URL.Parse(target[1 : len(target)-1])
+				if err != nil {
+					return "", fmt.Errorf("invalid link %q: %s", target, err)
+				}
+				return next.String(), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// baseURL returns the URL of the registry.
+func (c *Client) baseURL(registry string) string {
+	if c.PlainHTTP || isLocal(registry) {
+		return "http://" + registry
+	}
+	return "https://" + registry
+}
+
+// url returns the URL of the endpoint p of the repository of ref.
+func (c *Client) url(ref *Reference, p string) string {
+	return c.baseURL(ref.Registry) + "/v2/" + ref.Repository + "/" + p
+}
+
+// get fetches the content at u, and returns it with the response.
+func (c *Client) get(u string, header http.Header) ([]byte, *http.Response, error) {
+	resp, err := c.do("GET", u, header, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp, fmt.Errorf("Failed to fetch %s : %s", u, resp.Status)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	return data, resp, err
+}
+
+// do sends a request to the registry, authenticated with the credentials of
+// c. A registry answering with a bearer token challenge is sent the request
+// again with a token from its token service.
+func (c *Client) do(method, u string, header http.Header, body []byte) (*http.Response, error) {
+	resp, err := c.send(method, u, header, body, c.Token)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || c.Token != "" {
+		return resp, err
+	}
+
+	challenge := resp.Header.Get("WWW-Authenticate")
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	token, err := c.fetchToken(parseChallenge(challenge[len("bearer "):]))
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.tokens[resp.Request.URL.Host] = token
+	c.mu.Unlock()
+	return c.send(method, u, header, body, token)
+}
+
+// send sends a request, with token as bearer token if not empty.
+func (c *Client) send(method, u string, header http.Header, body []byte, token string) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, u, r)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if token == "" {
+		c.mu.Lock()
+		token = c.tokens[req.URL.Host]
+		c.mu.Unlock()
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if c.Username != "" || c.Password != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	resp, err := c.client.Do(req)
+	if err == nil && token != "" && resp.StatusCode == http.StatusUnauthorized && token != c.Token {
+		// The token cached for the host may not grant the scope of this
+		// request.
+		c.mu.Lock()
+		delete(c.tokens, req.URL.Host)
+		c.mu.Unlock()
+	}
+	return resp, err
+}
+
+// fetchToken requests a bearer token from the token service of a registry
+// challenge.
+func (c *Client) fetchToken(params map[string]string) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || !realm.IsAbs() {
+		return "", fmt.Errorf("invalid token service %q", params["realm"])
+	}
+	q := realm.Query()
+	for _, k := range []string{"service", "scope"} {
+		if v, ok := params[k]; ok {
+			q.Set(k, v)
+		}
+	}
+	realm.RawQuery = q.Encode()
+
+	req, err := http.NewRequest("GET", realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if c.Username != "" || c.Password != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("could not get a token from %s: %s", realm.Host, resp.Status)
+	}
+
+	var t struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&t); err != nil {
+		return "", fmt.Errorf("invalid token from %s: %s", realm.Host, err)
+	}
+	if t.Token == "" {
+		t.Token = t.AccessToken
+	}
+	if t.Token == "" {
+		return "", fmt.Errorf("no token from %s", realm.Host)
+	}
+	return t.Token, nil
+}
+
+// parseChallenge parses the parameters of a WWW-Authenticate challenge, such
+// as realm="https://auth.example.com/token",service="registry".
+func parseChallenge(s string) map[string]string {
+	params := map[string]string{}
+	for s = strings.TrimSpace(s); s != ""; {
+		i := strings.Index(s, "=")
+		if i == -1 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:i]))
+		s = strings.TrimSpace(s[i+1:])
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := strings.Index(s[1:], `"`)
+			if end == -1 {
+				value, s = s[1:], ""
+			} else {
+				value, s = s[1:end+1], s[end+2:]
+			}
+		} else if end := strings.Index(s, ","); end != -1 {
+			value, s = s[:end], s[end:]
+		} else {
+			value, s = s, ""
+		}
+		params[key] = value
+		s = strings.TrimPrefix(strings.TrimSpace(s), ",")
+		s = strings.TrimSpace(s)
+	}
+	return params
+}
+
+// Digest returns the sha256 digest of data, as used by OCI registries.
+func Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,119 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/rodcloutier/draft-packs/pkg/oci"
+	"github.com/rodcloutier/draft-packs/pkg/oci/ocitest"
+)
+
+func TestPushPull(t *testing.T) {
+	registry := ocitest.NewRegistry()
+	defer registry.Stop()
+
+	c := oci.NewClient(nil)
+	ref, err := oci.ParseReference("oci://" + registry.Host() + "/packs/golang:1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := []byte(`{"name":"golang","version":"1.0.0"}`)
+	archive := []byte("not really an archive")
+	prov := []byte("not really a provenance file")
+
+	digest, err := c.Push(ref, config, archive, prov)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Push(&oci.Reference{Registry: ref.Registry, Repository: ref.Repository, Tag: "1.1.0"}, config, archive, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	resolved, err := c.Resolve(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved != digest {
+		t.Errorf("Expected digest %s, got %s", digest, resolved)
+	}
+
+	p, err := c.Pull(&oci.Reference{Registry: ref.Registry, Repository: ref.Repository, Digest: digest})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(p.Config, config) || !bytes.Equal(p.Archive, archive) || !bytes.Equal(p.Provenance, prov) {
+		t.Errorf("Pulled pack does not match the pushed one: %+v", p)
+	}
+	if layer := p.Manifest.Layer(oci.ArchiveMediaType); layer == nil || layer.Digest != oci.Digest(archive) {
+		t.Errorf("Expected the archive layer, got %+v", p.Manifest.Layers)
+	}
+
+	// The lists are followed across pages
+	registry.SetPageSize(1)
+	registry.PushImage("images/web", "1.0.0")
+
+	tags, err := c.Tags(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := []string{"1.0.0", "1.1.0"}; !reflect.DeepEqual(tags, expect) {
+		t.Errorf("Expected tags %v, got %v", expect, tags)
+	}
+
+	repos, err := c.Catalog(registry.Host())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := []string{"images/web", "packs/golang"}; !reflect.DeepEqual(repos, expect) {
+		t.Errorf("Expected repositories %v, got %v", expect, repos)
+	}
+
+	if _, err := c.Pull(&oci.Reference{Registry: ref.Registry, Repository: ref.Repository, Tag: "2.0.0"}); err == nil {
+		t.Error("Expected an error for an unknown tag")
+	}
+	if _, _, err := c.Manifest(&oci.Reference{Registry: ref.Registry, Repository: "images/web", Tag: "1.0.0"}); err != oci.ErrNotPack {
+		t.Errorf("Expected %s for an image, got %v", oci.ErrNotPack, err)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	registry := ocitest.NewRegistry()
+	defer registry.Stop()
+	ref := &oci.Reference{Registry: registry.Host(), Repository: "golang", Tag: "1.0.0"}
+
+	for _, tokenAuth := range []bool{false, true} {
+		if tokenAuth {
+			registry.SetTokenAuth("user", "secret")
+		} else {
+			registry.SetBasicAuth("user", "secret")
+		}
+
+		if _, err := oci.NewClient(nil).Push(ref, []byte("{}"), []byte("archive"), nil); err == nil {
+			t.Errorf("token auth %t: expected an error without credentials", tokenAuth)
+		}
+
+		c := oci.NewClient(nil)
+		c.Username, c.Password = "user", "secret"
+		if _, err := c.Push(ref, []byte("{}"), []byte("archive"), nil); err != nil {
+			t.Errorf("token auth %t: %s", tokenAuth, err)
+		}
+		if _, err := c.Pull(ref); err != nil {
+			t.Errorf("token auth %t: %s", tokenAuth, err)
+		}
+	}
+}
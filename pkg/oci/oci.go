@@ -0,0 +1,188 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package oci stores pack archives as artifacts of an OCI registry.
+//
+// A pack is given by a reference such as oci://registry.example.com/packs/golang:1.0.0
+// or oci://registry.example.com/packs/golang@sha256:<digest>. Its manifest has
+// a config holding the pack metadata, the pack archive as first layer and,
+// for signed packs, the provenance file as second layer.
+package oci
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// Scheme is the URL scheme of the references to packs in OCI registries.
+const Scheme = "oci"
+
+// The media types of the pack artifacts.
+const (
+	ManifestMediaType   = "application/vnd.oci.image.manifest.v1+json"
+	ConfigMediaType     = "application/vnd.draft.pack.config.v1+json"
+	ArchiveMediaType    = "application/vnd.draft.pack.content.v1.tar+gzip"
+	ProvenanceMediaType = "application/vnd.draft.pack.provenance.v1.prov"
+)
+
+var (
+	tagPattern    = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+	digestPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+)
+
+// Reference is the reference of a pack in an OCI registry.
+type Reference struct {
+	// Registry is the host, and port, of the registry.
+	Registry string
+	// Repository is the name of the pack in the registry, such as
+	// packs/golang.
+	Repository string
+	// Tag is the tag of the pack, empty when given by digest.
+	Tag string
+	// Digest is the digest of the manifest of the pack.
+	Digest string
+}
+
+// ParseReference parses the reference s, with or without the oci:// prefix.
+// The reference may have neither a tag nor a digest.
+func ParseReference(s string) (*Reference, error) {
+	ref := &Reference{}
+	rest := strings.TrimPrefix(s, Scheme+"://")
+
+	parts := strings.SplitN(rest, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid OCI reference %q, expected oci://REGISTRY/NAME[:TAG|@DIGEST]", s)
+	}
+	ref.Registry, rest = parts[0], parts[1]
+
+	if i := strings.Index(rest, "@"); i != -1 {
+		rest, ref.Digest = rest[:i], rest[i+1:]
+		if !digestPattern.MatchString(ref.Digest) {
+			return nil, fmt.Errorf("invalid digest %q in OCI reference %q", ref.Digest, s)
+		}
+	}
+	if i := strings.LastIndex(rest, ":"); i != -1 && !strings.Contains(rest[i:], "/") {
+		rest, ref.Tag = rest[:i], rest[i+1:]
+		if !tagPattern.MatchString(ref.Tag) {
+			return nil, fmt.Errorf("invalid tag %q in OCI reference %q", ref.Tag, s)
+		}
+	}
+	if rest == "" || strings.ToLower(rest) != rest {
+		return nil, fmt.Errorf("invalid repository %q in OCI reference %q, expected a lower case name", rest, s)
+	}
+	ref.Repository = rest
+	return ref, nil
+}
+
+// String returns the reference, with the oci:// prefix.
+func (r *Reference) String() string {
+	s := Scheme + "://" + r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// Name returns the name of the pack, the last element of its repository.
+func (r *Reference) Name() string {
+	return r.Repository[strings.LastIndex(r.Repository, "/")+1:]
+}
+
+// manifestReference returns the reference of the manifest in the registry,
+// its digest if known.
+func (r *Reference) manifestReference() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	if r.Tag != "" {
+		return r.Tag
+	}
+	return "latest"
+}
+
+// TagFromVersion returns the tag of the version of a pack. OCI tags cannot
+// hold the + of semantic versions build metadata.
+func TagFromVersion(version string) string {
+	return strings.Replace(version, "+", "_", -1)
+}
+
+// VersionFromTag returns the version of the pack tagged with tag.
+func VersionFromTag(tag string) string {
+	return strings.Replace(tag, "_", "+", -1)
+}
+
+// ArchiveName returns the file name of the archive of the pack of the
+// reference s.
+func ArchiveName(s string) (string, error) {
+	ref, err := ParseReference(s)
+	if err != nil {
+		return "", err
+	}
+	version := ref.Tag
+	if version == "" {
+		version = strings.TrimPrefix(ref.Digest, "sha256:")
+		if len(version) > 12 {
+			version = version[:12]
+		}
+	}
+	if version == "" {
+		return ref.Name() + ".tgz", nil
+	}
+	return ref.Name() + "-" + VersionFromTag(version) + ".tgz", nil
+}
+
+// Descriptor describes a blob of a manifest.
+type Descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+// Manifest is an OCI image manifest describing a pack.
+type Manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType"`
+	Config        Descriptor   `json:"config"`
+	Layers        []Descriptor `json:"layers"`
+}
+
+// Layer returns the layer of the manifest with the media type mediaType, or
+// nil if there is none.
+func (m *Manifest) Layer(mediaType string) *Descriptor {
+	for i := range m.Layers {
+		if m.Layers[i].MediaType == mediaType {
+			return &m.Layers[i]
+		}
+	}
+	return nil
+}
+
+// isLocal returns whether the registry host runs on the local machine, where
+// registries are commonly served over plain HTTP.
+func isLocal(registry string) bool {
+	host := registry
+	if h, _, err := net.SplitHostPort(registry); err == nil {
+		host = h
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
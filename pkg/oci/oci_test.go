@@ -0,0 +1,69 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import "testing"
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		ref    string
+		expect Reference
+		fail   bool
+	}{
+		{ref: "oci://registry.example.com/packs/golang:1.0.0", expect: Reference{"registry.example.com", "packs/golang", "1.0.0", ""}},
+		{ref: "localhost:5000/golang", expect: Reference{"localhost:5000", "golang", "", ""}},
+		{
+			ref:    "oci://localhost:5000/packs/golang:1.0.0@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			expect: Reference{"localhost:5000", "packs/golang", "1.0.0", "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"},
+		},
+		{ref: "oci://registry.example.com", fail: true},
+		{ref: "oci://registry.example.com/packs/golang:1.0+build", fail: true},
+		{ref: "oci://registry.example.com/packs/golang@sha256:abc", fail: true},
+		{ref: "oci://registry.example.com/Packs/golang", fail: true},
+	}
+
+	for _, tt := range tests {
+		ref, err := ParseReference(tt.ref)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("%s: expected an error", tt.ref)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", tt.ref, err)
+			continue
+		}
+		if *ref != tt.expect {
+			t.Errorf("%s: expected %+v, got %+v", tt.ref, tt.expect, *ref)
+		}
+	}
+}
+
+func TestArchiveName(t *testing.T) {
+	tests := map[string]string{
+		"oci://localhost:5000/packs/golang:1.0.0_build.1":                                                           "golang-1.0.0+build.1.tgz",
+		"oci://localhost:5000/packs/golang@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef": "golang-0123456789ab.tgz",
+		"oci://localhost:5000/golang":                                                                               "golang.tgz",
+	}
+	for ref, expect := range tests {
+		name, err := ArchiveName(ref)
+		if err != nil {
+			t.Errorf("%s: %s", ref, err)
+		} else if name != expect {
+			t.Errorf("%s: expected %s, got %s", ref, expect, name)
+		}
+	}
+}
@@ -0,0 +1,347 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ocitest provides an in-process OCI registry for testing.
+package ocitest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// token is the bearer token issued by the token service of the registry.
+const token = "ocitest-token"
+
+// imageManifestMediaType is the media type of the manifests of the images.
+const imageManifestMediaType = "application/vnd.docker.distribution.manifest.v2+json"
+
+// Registry is an in-memory implementation of the distribution API of OCI
+// registries, sufficient to push and pull packs.
+type Registry struct {
+	srv *httptest.Server
+
+	username  string
+	password  string
+	tokenAuth bool
+	pageSize  int
+
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string]*manifest
+	tags      map[string]map[string]string
+	uploads   int
+}
+
+// manifest is a manifest stored by the registry.
+type manifest struct {
+	mediaType string
+	data      []byte
+}
+
+// NewRegistry starts a registry for testing. It should be stopped with Stop.
+func NewRegistry() *Registry {
+	r := &Registry{
+		blobs:     map[string][]byte{},
+		manifests: map[string]*manifest{},
+		tags:      map[string]map[string]string{},
+	}
+	r.srv = httptest.NewServer(http.HandlerFunc(r.serve))
+	return r
+}
+
+// SetBasicAuth protects the registry with basic authentication.
+func (r *Registry) SetBasicAuth(username, password string) {
+	r.username = username
+	r.password = password
+	r.tokenAuth = false
+}
+
+// SetTokenAuth protects the registry with bearer tokens, issued by the token
+// service of the registry to the clients authenticated with the credentials.
+func (r *Registry) SetTokenAuth(username, password string) {
+	r.username = username
+	r.password = password
+	r.tokenAuth = true
+}
+
+// SetPageSize sets the number of entries of the pages of the repository and
+// tag lists, unlimited by default.
+func (r *Registry) SetPageSize(n int) {
+	r.pageSize = n
+}
+
+// PushImage stores a container image, which is not a pack, tagged as tag in
+// the repository name.
+func (r *Registry) PushImage(name, tag string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	config := []byte(`{"architecture":"amd64","os":"linux"}`)
+	layer := []byte("not really a layer")
+	r.blobs[digest(config)] = config
+	r.blobs[digest(layer)] = layer
+	data := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":"%s","config":{"mediaType":"%s","digest":"%s","size":%d},"layers":[{"mediaType":"%s","digest":"%s","size":%d}]}`,
+		imageManifestMediaType, "application/vnd.docker.container.image.v1+json", digest(config), len(config),
+		"application/vnd.docker.image.rootfs.diff.tar.gzip", digest(layer), len(layer)))
+
+	d := digest(data)
+	r.manifests[d] = &manifest{mediaType: imageManifestMediaType, data: data}
+	if r.tags[name] == nil {
+		r.tags[name] = map[string]string{}
+	}
+	r.tags[name][tag] = d
+}
+
+// Host returns the host and port of the registry, as used in references.
+func (r *Registry) Host() string {
+	return strings.TrimPrefix(r.srv.URL, "http://")
+}
+
+// Stop stops the registry and closes all connections.
+func (r *Registry) Stop() {
+	r.srv.Close()
+}
+
+func (r *Registry) serve(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/token" {
+		r.serveToken(w, req)
+		return
+	}
+	if !strings.HasPrefix(req.URL.Path, "/v2/") {
+		http.NotFound(w, req)
+		return
+	}
+	p := strings.TrimPrefix(req.URL.Path, "/v2/")
+
+	var name string
+	if i := strings.LastIndex(p, "/manifests/"); i != -1 {
+		name = p[:i]
+	} else if i := strings.LastIndex(p, "/blobs/"); i != -1 {
+		name = p[:i]
+	} else {
+		name = strings.TrimSuffix(p, "/tags/list")
+	}
+	if !r.authorized(w, req, name) {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case p == "":
+		w.WriteHeader(http.StatusOK)
+	case p == "_catalog":
+		r.serveCatalog(w, req)
+	case strings.HasSuffix(p, "/tags/list"):
+		r.serveTags(w, req, name)
+	case strings.Contains(p, "/manifests/"):
+		r.serveManifest(w, req, name, p[strings.LastIndex(p, "/manifests/")+len("/manifests/"):])
+	case strings.Contains(p, "/blobs/uploads/"):
+		r.serveUpload(w, req, name)
+	case strings.Contains(p, "/blobs/"):
+		r.serveBlob(w, req, p[strings.LastIndex(p, "/blobs/")+len("/blobs/"):])
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+// authorized returns whether the request carries the credentials required by
+// the registry, if any, and challenges the client otherwise.
+func (r *Registry) authorized(w http.ResponseWriter, req *http.Request, name string) bool {
+	if r.username == "" && r.password == "" {
+		return true
+	}
+	if r.tokenAuth {
+		if req.Header.Get("Authorization") == "Bearer "+token {
+			return true
+		}
+		scope := "repository:" + name + ":pull"
+		if req.Method != "GET" && req.Method != "HEAD" {
+			scope += ",push"
+		}
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="ocitest",scope="%s"`, r.srv.URL, scope))
+	} else {
+		if username, password, ok := req.BasicAuth(); ok && username == r.username && password == r.password {
+			return true
+		}
+		w.Header().Set("WWW-Authenticate", `Basic realm="ocitest"`)
+	}
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+	return false
+}
+
+func (r *Registry) serveToken(w http.ResponseWriter, req *http.Request) {
+	username, password, ok := req.BasicAuth()
+	if !r.tokenAuth || !ok || username != r.username || password != r.password {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	writeJSON(w, map[string]string{"token": token})
+}
+
+func (r *Registry) serveCatalog(w http.ResponseWriter, req *http.Request) {
+	names := []string{}
+	for name := range r.tags {
+		names = append(names, name)
+	}
+	writeJSON(w, map[string]interface{}{"repositories": r.page(w, req, names)})
+}
+
+func (r *Registry) serveTags(w http.ResponseWriter, req *http.Request, name string) {
+	tags, ok := r.tags[name]
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	list := []string{}
+	for tag := range tags {
+		list = append(list, tag)
+	}
+	writeJSON(w, map[string]interface{}{"name": name, "tags": r.page(w, req, list)})
+}
+
+// page returns the page of the sorted list requested by the n and last query
+// parameters, and links to the next page if any.
+func (r *Registry) page(w http.ResponseWriter, req *http.Request, list []string) []string {
+	sort.Strings(list)
+	q := req.URL.Query()
+	if last := q.Get("last"); last != "" {
+		i := sort.SearchStrings(list, last)
+		if i < len(list) && list[i] == last {
+			i++
+		}
+		list = list[i:]
+	}
+	n := r.pageSize
+	if v, err := strconv.Atoi(q.Get("n")); err == nil && v > 0 {
+		n = v
+	}
+	if n > 0 && len(list) > n {
+		list = list[:n]
+		w.Header().Set("Link", fmt.Sprintf(`<%s?last=%s&n=%d>; rel="next"`, req.URL.Path, list[n-1], n))
+	}
+	return list
+}
+
+func (r *Registry) serveManifest(w http.ResponseWriter, req *http.Request, name, ref string) {
+	switch req.Method {
+	case "PUT":
+		data, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var m struct {
+			Config struct{ Digest string }
+			Layers []struct{ Digest string }
+		}
+		if err := json.Unmarshal(data, &m); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, d := range append(m.Layers, struct{ Digest string }{m.Config.Digest}) {
+			if _, ok := r.blobs[d.Digest]; !ok {
+				http.Error(w, "unknown blob "+d.Digest, http.StatusBadRequest)
+				return
+			}
+		}
+
+		d := digest(data)
+		r.manifests[d] = &manifest{mediaType: req.Header.Get("Content-Type"), data: data}
+		if r.tags[name] == nil {
+			r.tags[name] = map[string]string{}
+		}
+		if !strings.HasPrefix(ref, "sha256:") {
+			r.tags[name][ref] = d
+		}
+		w.Header().Set("Docker-Content-Digest", d)
+		w.WriteHeader(http.StatusCreated)
+	case "GET", "HEAD":
+		d := ref
+		if !strings.HasPrefix(ref, "sha256:") {
+			d = r.tags[name][ref]
+		}
+		m, ok := r.manifests[d]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("Content-Type", m.mediaType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(m.data)))
+		w.Header().Set("Docker-Content-Digest", d)
+		if req.Method == "GET" {
+			w.Write(m.data)
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (r *Registry) serveUpload(w http.ResponseWriter, req *http.Request, name string) {
+	switch req.Method {
+	case "POST":
+		r.uploads++
+		w.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/uploads/%d", name, r.uploads))
+		w.WriteHeader(http.StatusAccepted)
+	case "PUT":
+		data, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		d := req.URL.Query().Get("digest")
+		if d != digest(data) {
+			http.Error(w, "digest mismatch", http.StatusBadRequest)
+			return
+		}
+		r.blobs[d] = data
+		w.Header().Set("Docker-Content-Digest", d)
+		w.WriteHeader(http.StatusCreated)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (r *Registry) serveBlob(w http.ResponseWriter, req *http.Request, d string) {
+	data, ok := r.blobs[d]
+	if !ok || (req.Method != "GET" && req.Method != "HEAD") {
+		http.NotFound(w, req)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("Docker-Content-Digest", d)
+	if req.Method == "GET" {
+		w.Write(data)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,275 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package packutil contains tools for working with pack directories.
+package packutil
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/Azure/draft/pkg/draft/pack"
+)
+
+const (
+	// MetadataFile is the name of the pack metadata file.
+	MetadataFile = "Pack.yaml"
+	// DetectFile is the name of the detection script.
+	DetectFile = "detect"
+	// DockerFile is the name of the Dockerfile.
+	DockerFile = "Dockerfile"
+	// ChartDir is the name of the directory holding the chart.
+	ChartDir = "chart"
+)
+
+// DefaultVersion is the version given to newly created packs.
+const DefaultVersion = "0.1.0"
+
+const defaultHelmignore = `# Patterns to ignore when building packages.
+# This supports shell glob matching, relative path matching, and
+# negation (prefixed with !). Only one pattern per line.
+.DS_Store
+# Common VCS dirs
+.git/
+.gitignore
+.bzr/
+.bzrignore
+.hg/
+.hgignore
+.svn/
+# Common backup files
+*.swp
+*.bak
+*.tmp
+*~
+# Various IDEs
+.project
+.idea/
+*.tmproj
+`
+
+const defaultChartfile = `description: A Helm chart for Kubernetes
+name: %s
+`
+
+const defaultValues = `# Default values for %s.
+# This is a YAML-formatted file.
+# Declare variables to be passed into your templates.
+replicaCount: 1
+image:
+  registry: docker.io
+  org: library
+  name: %s
+  tag: latest
+  pullPolicy: IfNotPresent
+service:
+  name: %s
+  type: ClusterIP
+  externalPort: 80
+  internalPort: 8080
+resources:
+  limits:
+    cpu: 100m
+    memory: 128Mi
+  requests:
+    cpu: 100m
+    memory: 128Mi
+`
+
+const defaultDeployment = `apiVersion: extensions/v1beta1
+kind: Deployment
+metadata:
+  name: {{ template "fullname" . }}
+  labels:
+    chart: "{{ .Chart.Name }}-{{ .Chart.Version | replace "+" "_" }}"
+spec:
+  replicas: {{ .Values.replicaCount }}
+  template:
+    metadata:
+      labels:
+        app: {{ template "fullname" . }}
+    spec:
+      containers:
+      - name: {{ .Chart.Name }}
+        image: "{{ .Values.image.registry }}/{{ .Values.image.org }}/{{ .Values.image.name }}:{{ .Values.image.tag }}"
+        imagePullPolicy: {{ .Values.image.pullPolicy }}
+        ports:
+        - containerPort: {{ .Values.service.internalPort }}
+        livenessProbe:
+          httpGet:
+            path: /
+            port: {{ .Values.service.internalPort }}
+        readinessProbe:
+          httpGet:
+            path: /
+            port: {{ .Values.service.internalPort }}
+        resources:
+{{ toYaml .Values.resources | indent 12 }}
+`
+
+const defaultService = `apiVersion: v1
+kind: Service
+metadata:
+  name: {{ template "fullname" . }}
+  labels:
+    chart: "{{ .Chart.Name }}-{{ .Chart.Version | replace "+" "_" }}"
+spec:
+  type: {{ .Values.service.type }}
+  ports:
+  - port: {{ .Values.service.externalPort }}
+    targetPort: {{ .Values.service.internalPort }}
+    protocol: TCP
+    name: {{ .Values.service.name }}
+  selector:
+    app: {{ template "fullname" . }}
+`
+
+const defaultIngress = `apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: {{ template "fullname" . }}
+  labels:
+    chart: "{{ .Chart.Name }}-{{ .Chart.Version | replace "+" "_" }}"
+spec:
+  rules:
+  - host: {{ .Release.Name }}.{{ .Values.basedomain }}
+    http:
+      paths:
+      - path: /
+        backend:
+          serviceName: {{ template "fullname" . }}
+          servicePort: {{ .Values.service.externalPort }}
+`
+
+const defaultHelpers = `{{/* vim: set filetype=mustache: */}}
+{{/*
+Expand the name of the chart.
+*/}}
+{{- define "name" -}}
+{{- default .Chart.Name .Values.nameOverride | trunc 63 | trimSuffix "-" -}}
+{{- end -}}
+
+{{/*
+Create a default fully qualified app name.
+We truncate at 63 chars because some Kubernetes name fields are limited to this (by the DNS naming spec).
+*/}}
+{{- define "fullname" -}}
+{{- $name := default .Chart.Name .Values.nameOverride -}}
+{{- printf "%s-%s" .Release.Name $name | trunc 63 | trimSuffix "-" -}}
+{{- end -}}
+`
+
+const defaultNotes = `
+{{- if contains "NodePort" .Values.service.type }}
+  Get the application URL by running these commands:
+  export NODE_PORT=$(kubectl get --namespace {{ .Release.Namespace }} -o jsonpath="{.spec.ports[0].nodePort}" services {{ template "fullname" . }})
+  export NODE_IP=$(kubectl get nodes --namespace {{ .Release.Namespace }} -o jsonpath="{.items[0].status.addresses[0].address}")
+  echo http://$NODE_IP:$NODE_PORT/login
+{{- else if contains "LoadBalancer" .Values.service.type }}
+  Get the application URL by running these commands:
+     NOTE: It may take a few minutes for the LoadBalancer IP to be available.
+           You can watch the status of by running 'kubectl get svc -w {{ template "fullname" . }}'
+  export SERVICE_IP=$(kubectl get svc --namespace {{ .Release.Namespace }} {{ template "fullname" . }} -o jsonpath='{.status.loadBalancer.ingress[0].ip}')
+  echo http://$SERVICE_IP:{{ .Values.service.externalPort }}
+{{- else }}
+  http://{{ .Release.Name }}.{{ .Values.basedomain }} to access your application
+{{- end }}
+`
+
+const defaultDockerfile = `# Replace with the instructions needed to build the application detected by
+# this pack.
+FROM alpine:3.6
+EXPOSE 8080
+CMD ["/bin/sh", "-c", "echo 'Replace this Dockerfile' && sleep 3600"]
+`
+
+const defaultDetect = `#!/usr/bin/env bash
+# bin/detect <build-dir>
+#
+# Print the name of the detected language and exit with 0 when the
+# application in <build-dir> should use the %s pack, exit with 1 otherwise.
+set -e
+
+build=$(cd "$1/" && pwd)
+
+# Replace with the detection logic of the pack.
+if false; then
+  echo %s
+else
+  exit 1
+fi
+`
+
+// Create creates a new pack in the directory dir, named after name.
+//
+// The pack contains the metadata, a chart with a deployment, a service and an
+// ingress, a placeholder Dockerfile and a detect script that never matches.
+//
+// It returns the path to the new pack.
+func Create(name, dir string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid pack name %q", name)
+	}
+
+	path, err := filepath.Abs(filepath.Join(dir, name))
+	if err != nil {
+		return path, err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return path, fmt.Errorf("%s already exists", path)
+	}
+
+	md, err := yaml.Marshal(&pack.Metadata{
+		Name:        name,
+		Version:     DefaultVersion,
+		Description: fmt.Sprintf("Draft pack for %s applications", name),
+	})
+	if err != nil {
+		return path, err
+	}
+
+	files := []struct {
+		path    string
+		content []byte
+		mode    os.FileMode
+	}{
+		{path: MetadataFile, content: md, mode: 0644},
+		{path: DockerFile, content: []byte(defaultDockerfile), mode: 0644},
+		{path: DetectFile, content: []byte(fmt.Sprintf(defaultDetect, name, name)), mode: 0755},
+		{path: "chart/.helmignore", content: []byte(defaultHelmignore), mode: 0644},
+		{path: "chart/Chart.yaml", content: []byte(fmt.Sprintf(defaultChartfile, name)), mode: 0644},
+		{path: "chart/values.yaml", content: []byte(fmt.Sprintf(defaultValues, name, name, name)), mode: 0644},
+		{path: "chart/templates/_helpers.tpl", content: []byte(defaultHelpers), mode: 0644},
+		{path: "chart/templates/deployment.yaml", content: []byte(defaultDeployment), mode: 0644},
+		{path: "chart/templates/service.yaml", content: []byte(defaultService), mode: 0644},
+		{path: "chart/templates/ingress.yaml", content: []byte(defaultIngress), mode: 0644},
+		{path: "chart/templates/NOTES.txt", content: []byte(defaultNotes), mode: 0644},
+	}
+
+	for _, f := range files {
+		p := filepath.Join(path, filepath.FromSlash(f.path))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return path, err
+		}
+		if err := ioutil.WriteFile(p, f.content, f.mode); err != nil {
+			return path, err
+		}
+	}
+	return path, nil
+}
@@ -0,0 +1,54 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packutil_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rodcloutier/draft-packs/pkg/lint"
+	"github.com/rodcloutier/draft-packs/pkg/packutil"
+)
+
+func TestCreate(t *testing.T) {
+	tdir, err := ioutil.TempDir("", "draft-packs-create-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tdir)
+
+	p, err := packutil.Create("foo", tdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := filepath.Join(tdir, "foo"); p != expect {
+		t.Errorf("Expected pack at %s, got %s", expect, p)
+	}
+
+	// A freshly created pack must be usable as is.
+	l, err := lint.Dir(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range l.Messages {
+		t.Errorf("Unexpected lint message: %s", m.Error())
+	}
+
+	if _, err := packutil.Create("foo", tdir); err == nil {
+		t.Error("Expected an error when creating over an existing pack")
+	}
+}
@@ -0,0 +1,332 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packutil
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/Azure/draft/pkg/draft/pack"
+	"k8s.io/helm/pkg/proto/hapi/chart"
+)
+
+// PlaceholdersFile is the name of the file in which a starter pack declares
+// the placeholders substituted when a pack is created from it.
+const PlaceholdersFile = "Placeholders.yaml"
+
+const (
+	leftDelim  = "{{"
+	rightDelim = "}}"
+)
+
+// placeholderRe matches a placeholder reference, [[ name ]], in the files of
+// a starter pack. The delimiters differ from the template delimiters so that
+// placeholders can be used anywhere, including inside templates.
+var placeholderRe = regexp.MustCompile(`\[\[\s*([A-Za-z_][A-Za-z0-9_]*)\s*\]\]`)
+
+var chartNameRe = regexp.MustCompile(`(?m)^name:.*$`)
+
+// Placeholder is a value declared by a starter pack that is substituted when
+// a pack is created from it.
+type Placeholder struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Default     string `json:"default,omitempty"`
+}
+
+// Placeholders is the content of the placeholders file of a starter pack.
+type Placeholders struct {
+	Placeholders []*Placeholder `json:"placeholders"`
+}
+
+// LoadPlaceholders reads the placeholders declared by the pack in dir.
+//
+// A pack without a placeholders file declares no placeholders.
+func LoadPlaceholders(dir string) (*Placeholders, error) {
+	p := &Placeholders{}
+	b, err := ioutil.ReadFile(filepath.Join(dir, PlaceholdersFile))
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(b, p); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %s", PlaceholdersFile, err)
+	}
+	return p, nil
+}
+
+// Resolve returns the value of every declared placeholder given the values
+// provided by the user.
+//
+// It fails if a value is provided for an undeclared placeholder or if a
+// placeholder without default is not provided.
+func (p *Placeholders) Resolve(values map[string]string) (map[string]string, error) {
+	declared := map[string]*Placeholder{}
+	for _, ph := range p.Placeholders {
+		declared[ph.Name] = ph
+	}
+
+	unknown := []string{}
+	for k := range values {
+		if _, ok := declared[k]; !ok {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown placeholder(s): %s", strings.Join(unknown, ", "))
+	}
+
+	resolved := map[string]string{}
+	missing := []string{}
+	for _, ph := range p.Placeholders {
+		if v, ok := values[ph.Name]; ok {
+			resolved[ph.Name] = v
+		} else if ph.Default != "" {
+			resolved[ph.Name] = ph.Default
+		} else {
+			missing = append(missing, ph.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing value for placeholder(s): %s", strings.Join(missing, ", "))
+	}
+	return resolved, nil
+}
+
+// Customize turns the copy of a starter pack in dir into the pack name.
+//
+// Every reference to the starter pack name found in the text files of the
+// pack is replaced by name, and the placeholders declared by the starter are
+// substituted with values. Within template actions, only string literals are
+// rewritten, leaving the template code untouched. Image references of the
+// Dockerfile FROM instructions are also left untouched.
+func Customize(dir, name string, values map[string]string) error {
+	placeholders, err := LoadPlaceholders(dir)
+	if err != nil {
+		return err
+	}
+	resolved, err := placeholders.Resolve(values)
+	if err != nil {
+		return err
+	}
+
+	old, err := starterName(dir)
+	if err != nil {
+		return err
+	}
+
+	err = filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !isText(data) {
+			return nil
+		}
+
+		content := substitute(string(data), resolved)
+		if old != "" && old != name {
+			if filepath.Base(path) == DockerFile {
+				content = renameDockerfile(content, old, name)
+			} else {
+				content = rename(content, old, name)
+			}
+		}
+		if content == string(data) {
+			return nil
+		}
+		return ioutil.WriteFile(path, []byte(content), fi.Mode().Perm())
+	})
+	if err != nil {
+		return err
+	}
+
+	// The chart is always named after the pack.
+	chartfile := filepath.Join(dir, ChartDir, "Chart.yaml")
+	if data, err := ioutil.ReadFile(chartfile); err == nil {
+		data = chartNameRe.ReplaceAll(data, []byte("name: "+name))
+		if err := ioutil.WriteFile(chartfile, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	// Placeholders are consumed by the creation of the pack.
+	if err := os.Remove(filepath.Join(dir, PlaceholdersFile)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// starterName returns the name of the pack in dir, taken from the pack
+// metadata or from the chart when there is no metadata.
+func starterName(dir string) (string, error) {
+	if b, err := ioutil.ReadFile(filepath.Join(dir, MetadataFile)); err == nil {
+		md := &pack.Metadata{}
+		if err := yaml.Unmarshal(b, md); err != nil {
+			return "", fmt.Errorf("unable to parse %s: %s", MetadataFile, err)
+		}
+		if md.Name != "" {
+			return md.Name, nil
+		}
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(dir, ChartDir, "Chart.yaml")); err == nil {
+		md := &chart.Metadata{}
+		if err := yaml.Unmarshal(b, md); err != nil {
+			return "", fmt.Errorf("unable to parse Chart.yaml: %s", err)
+		}
+		return md.Name, nil
+	}
+	return "", nil
+}
+
+// isText reports whether data looks like the content of a text file.
+func isText(data []byte) bool {
+	return bytes.IndexByte(data, 0) == -1 && utf8.Valid(data)
+}
+
+// substitute replaces the placeholders references with their values.
+//
+// References to undeclared placeholders are left as is.
+func substitute(s string, values map[string]string) string {
+	if len(values) == 0 {
+		return s
+	}
+	return placeholderRe.ReplaceAllStringFunc(s, func(m string) string {
+		name := placeholderRe.FindStringSubmatch(m)[1]
+		if v, ok := values[name]; ok {
+			return v
+		}
+		return m
+	})
+}
+
+// renameDockerfile renames old to name in a Dockerfile, except in the FROM
+// instructions which reference base images.
+func renameDockerfile(s, old, name string) string {
+	lines := strings.SplitAfter(s, "\n")
+	for i, l := range lines {
+		if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(l)), "FROM ") {
+			continue
+		}
+		lines[i] = rename(l, old, name)
+	}
+	return strings.Join(lines, "")
+}
+
+// rename replaces the word old by name in s, respecting template delimiters:
+// inside an action, only the string literals are rewritten.
+func rename(s, old, name string) string {
+	var b bytes.Buffer
+	for {
+		start := strings.Index(s, leftDelim)
+		if start == -1 {
+			b.WriteString(replaceWord(s, old, name))
+			break
+		}
+		b.WriteString(replaceWord(s[:start], old, name))
+		s = s[start:]
+
+		end := strings.Index(s, rightDelim)
+		if end == -1 {
+			// Unterminated action, leave it alone.
+			b.WriteString(s)
+			break
+		}
+		end += len(rightDelim)
+		b.WriteString(replaceInLiterals(s[:end], old, name))
+		s = s[end:]
+	}
+	return b.String()
+}
+
+// replaceInLiterals replaces the word old by name in the quoted and raw
+// string literals of a template action.
+func replaceInLiterals(action, old, name string) string {
+	var b bytes.Buffer
+	for i := 0; i < len(action); i++ {
+		c := action[i]
+		if c != '"' && c != '`' {
+			b.WriteByte(c)
+			continue
+		}
+
+		// Find the end of the literal
+		j := i + 1
+		for ; j < len(action); j++ {
+			if c == '"' && action[j] == '\\' {
+				j++
+				continue
+			}
+			if action[j] == c {
+				break
+			}
+		}
+		if j >= len(action) {
+			b.WriteString(action[i:])
+			break
+		}
+		b.WriteByte(c)
+		b.WriteString(replaceWord(action[i+1:j], old, name))
+		b.WriteByte(c)
+		i = j
+	}
+	return b.String()
+}
+
+// replaceWord replaces the occurrences of old that are not part of a larger
+// identifier.
+func replaceWord(s, old, name string) string {
+	var b bytes.Buffer
+	pos := 0
+	for {
+		i := strings.Index(s[pos:], old)
+		if i == -1 {
+			b.WriteString(s[pos:])
+			break
+		}
+		start := pos + i
+		end := start + len(old)
+		b.WriteString(s[pos:start])
+		if (start > 0 && isWordChar(s[start-1])) || (end < len(s) && isWordChar(s[end])) {
+			b.WriteString(old)
+		} else {
+			b.WriteString(name)
+		}
+		pos = end
+	}
+	return b.String()
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
@@ -0,0 +1,121 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRename(t *testing.T) {
+	tests := []struct {
+		in, expect string
+	}{
+		{in: "golang", expect: "mygo"},
+		{in: "name: golang-app", expect: "name: mygo-app"},
+		{in: "golangci golang_x xgolang", expect: "golangci golang_x xgolang"},
+		{in: `{{ template "golang.fullname" . }}`, expect: `{{ template "mygo.fullname" . }}`},
+		{in: `{{ .Values.golang.port }} golang`, expect: `{{ .Values.golang.port }} mygo`},
+		{in: "{{ `golang` }}", expect: "{{ `mygo` }}"},
+		{in: "golang {{ unterminated golang", expect: "mygo {{ unterminated golang"},
+	}
+
+	for _, tt := range tests {
+		if got := rename(tt.in, "golang", "mygo"); got != tt.expect {
+			t.Errorf("rename(%q): expected %q, got %q", tt.in, tt.expect, got)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	p := &Placeholders{
+		Placeholders: []*Placeholder{
+			{Name: "port", Default: "8080"},
+			{Name: "image"},
+		},
+	}
+
+	if _, err := p.Resolve(map[string]string{}); err == nil {
+		t.Error("Expected an error for a missing placeholder value")
+	}
+	if _, err := p.Resolve(map[string]string{"image": "alpine", "nope": "x"}); err == nil {
+		t.Error("Expected an error for an unknown placeholder")
+	}
+
+	v, err := p.Resolve(map[string]string{"image": "alpine"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v["port"] != "8080" || v["image"] != "alpine" {
+		t.Errorf("Unexpected values %v", v)
+	}
+}
+
+func TestCustomize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "draft-packs-customize-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		MetadataFile:                    "Name: golang\nVersion: 0.1.0\n",
+		PlaceholdersFile:                "placeholders:\n- name: port\n  default: \"8080\"\n",
+		DockerFile:                      "FROM golang:onbuild\nLABEL app=golang\nEXPOSE [[ port ]]\n",
+		"chart/Chart.yaml":              "name: chart\n",
+		"chart/values.yaml":             "service:\n  name: golang\n  internalPort: [[port]]\n",
+		"chart/templates/_helpers.tpl":  `{{- define "golang.fullname" -}}{{ .Values.golang }}{{- end -}}`,
+		"chart/templates/service.yaml":  `name: {{ template "golang.fullname" . }}`,
+		"chart/templates/unrelated.txt": "[[ other ]]",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := Customize(dir, "mygo", map[string]string{"port": "3000"}); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := map[string]string{
+		MetadataFile:                    "Name: mygo\nVersion: 0.1.0\n",
+		DockerFile:                      "FROM golang:onbuild\nLABEL app=mygo\nEXPOSE 3000\n",
+		"chart/Chart.yaml":              "name: mygo\n",
+		"chart/values.yaml":             "service:\n  name: mygo\n  internalPort: 3000\n",
+		"chart/templates/_helpers.tpl":  `{{- define "mygo.fullname" -}}{{ .Values.golang }}{{- end -}}`,
+		"chart/templates/service.yaml":  `name: {{ template "mygo.fullname" . }}`,
+		"chart/templates/unrelated.txt": "[[ other ]]",
+	}
+	for name, content := range expect {
+		b, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != content {
+			t.Errorf("%s: expected %q, got %q", name, content, string(b))
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, PlaceholdersFile)); !os.IsNotExist(err) {
+		t.Errorf("Expected %s to be removed", PlaceholdersFile)
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packutil
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/ghodss/yaml"
+)
+
+// Dependency is a pack required by another pack, declared in the
+// dependencies of its metadata:
+//
+//	Name: go-grpc
+//	Version: 1.0.0
+//	dependencies:
+//	- name: stable/base-observability
+//	  version: ^1.2.0
+type Dependency struct {
+	// Name is the REPO/NAME reference of the required pack.
+	Name string `json:"name"`
+	// Version is a semantic versioning constraint the required pack must
+	// match. Any version matches when it is empty.
+	Version string `json:"version,omitempty"`
+}
+
+type dependencies struct {
+	Dependencies []*Dependency `json:"dependencies"`
+}
+
+// Validate checks that the dependency is a repository reference with a
+// valid version constraint.
+func (d *Dependency) Validate() error {
+	parts := strings.SplitN(d.Name, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("dependency %q must be a REPO/NAME reference", d.Name)
+	}
+	if d.Version != "" {
+		if _, err := semver.NewConstraint(d.Version); err != nil {
+			return fmt.Errorf("dependency %s: invalid version constraint %q: %s", d.Name, d.Version, err)
+		}
+	}
+	return nil
+}
+
+// ParseDependencies returns the dependencies declared in the pack metadata
+// data.
+func ParseDependencies(data []byte) ([]*Dependency, error) {
+	deps := &dependencies{}
+	if err := yaml.Unmarshal(data, deps); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %s", MetadataFile, err)
+	}
+	for _, d := range deps.Dependencies {
+		if err := d.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return deps.Dependencies, nil
+}
+
+// LoadDependencies returns the dependencies declared by the pack found at
+// path, a pack directory or a pack archive.
+//
+// A pack without metadata has no dependencies.
+func LoadDependencies(path string) ([]*Dependency, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	if fi.IsDir() {
+		data, err = ioutil.ReadFile(filepath.Join(path, MetadataFile))
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+	} else {
+		data, err = readArchiveMetadata(path)
+	}
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return ParseDependencies(data)
+}
+
+// readArchiveMetadata returns the content of the metadata file of the pack
+// archive path, or nil if it has none.
+func readArchiveMetadata(archive string) ([]byte, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		// The metadata is at the root of the top level directory
+		name := path.Clean(h.Name)
+		if path.Base(name) == MetadataFile && strings.Count(name, "/") == 1 {
+			return ioutil.ReadAll(tr)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packutil
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const dependentMetadata = `Name: go-grpc
+Version: 1.0.0
+dependencies:
+- name: stable/base-observability
+  version: ^1.2.0
+- name: stable/protoc
+`
+
+func TestLoadDependencies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "draft-packs-dependencies-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	packDir := filepath.Join(dir, "go-grpc")
+	if err := os.Mkdir(packDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// No metadata, no dependencies
+	deps, err := LoadDependencies(packDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deps) != 0 {
+		t.Errorf("Expected no dependencies, got %d", len(deps))
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(packDir, MetadataFile), []byte(dependentMetadata), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := filepath.Join(dir, "go-grpc-1.0.0.tgz")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	for name, content := range map[string]string{"go-grpc/" + MetadataFile: dependentMetadata, "go-grpc/chart/" + MetadataFile: "Name: other\n"} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	gw.Close()
+	f.Close()
+
+	for _, path := range []string{packDir, archive} {
+		deps, err := LoadDependencies(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(deps) != 2 {
+			t.Fatalf("%s: expected 2 dependencies, got %d", path, len(deps))
+		}
+		if deps[0].Name != "stable/base-observability" || deps[0].Version != "^1.2.0" || deps[1].Name != "stable/protoc" {
+			t.Errorf("%s: unexpected dependencies %v, %v", path, deps[0], deps[1])
+		}
+	}
+}
+
+func TestParseDependenciesInvalid(t *testing.T) {
+	for _, data := range []string{
+		"dependencies:\n- name: base\n",
+		"dependencies:\n- name: stable/base\n  version: not a constraint\n",
+	} {
+		if _, err := ParseDependencies([]byte(data)); err == nil {
+			t.Errorf("Expected an error for %q", data)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packutil
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Expand extracts a gzipped pack archive into dir and returns the name of the
+// top level directory of the archive.
+//
+// File modes recorded in the archive are preserved.
+func Expand(dir string, r io.Reader) (string, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return "", err
+	}
+	defer gr.Close()
+
+	top := ""
+	tr := tar.NewReader(gr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		name := filepath.Clean(filepath.FromSlash(h.Name))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("illegal file path in archive: %s", h.Name)
+		}
+		parts := strings.SplitN(name, string(filepath.Separator), 2)
+		if top == "" {
+			top = parts[0]
+		} else if top != parts[0] {
+			return "", errors.New("archive must contain a single top level directory")
+		}
+
+		dest := filepath.Join(dir, name)
+		switch h.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(dest, 0755); err != nil {
+				return "", err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+				return "", err
+			}
+			out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(h.Mode).Perm())
+			if err != nil {
+				return "", err
+			}
+			_, err = io.Copy(out, tr)
+			out.Close()
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+	if top == "" {
+		return "", errors.New("archive is empty")
+	}
+	return top, nil
+}
+
+// Copy copies the pack found at src, a pack directory or a pack archive, to
+// the directory dest.
+func Copy(src, dest string) error {
+	fi, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		return copyDir(src, dest)
+	}
+
+	tmp, err := ioutil.TempDir("", "draft-packs-expand-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	top, err := Expand(tmp, f)
+	if err != nil {
+		return fmt.Errorf("could not expand %s: %s", src, err)
+	}
+	return copyDir(filepath.Join(tmp, top), dest)
+}
+
+// copyDir recursively copies the directory src to dest, preserving modes.
+func copyDir(src, dest string) error {
+	return filepath.Walk(src, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+
+		if fi.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, data, fi.Mode().Perm())
+	})
+}
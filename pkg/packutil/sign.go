@@ -0,0 +1,104 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packutil
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure/draft/pkg/draft/pack"
+	"github.com/ghodss/yaml"
+	"golang.org/x/crypto/openpgp/clearsign"
+	"golang.org/x/crypto/openpgp/packet"
+	"k8s.io/helm/pkg/provenance"
+)
+
+// ProvenanceExt is the extension of the provenance file of a pack archive.
+const ProvenanceExt = ".prov"
+
+var defaultPGPConfig = packet.Config{
+	DefaultHash: crypto.SHA512,
+}
+
+// Sign returns the provenance of the pack archive, described by the metadata
+// md, signed by signatory.
+//
+// The provenance has the format of the Helm provenance files, the metadata
+// and the digest of the archive in a clear signed message, so that it is
+// verified by provenance.Signatory.Verify.
+func Sign(archive string, md *pack.Metadata, signatory *provenance.Signatory) (string, error) {
+	if signatory.Entity == nil || signatory.Entity.PrivateKey == nil {
+		return "", errors.New("private key not found")
+	}
+	if md == nil {
+		return "", errors.New("pack metadata is required to sign a pack")
+	}
+	if fi, err := os.Stat(archive); err != nil {
+		return "", err
+	} else if fi.IsDir() {
+		return "", errors.New("cannot sign a directory")
+	}
+
+	b, err := messageBlock(archive, md)
+	if err != nil {
+		return "", err
+	}
+
+	out := bytes.NewBuffer(nil)
+	w, err := clearsign.Encode(out, signatory.Entity.PrivateKey, &defaultPGPConfig)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(w, b); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// messageBlock returns the metadata md and the digest of the archive.
+func messageBlock(archive string, md *pack.Metadata) (*bytes.Buffer, error) {
+	digest, err := provenance.DigestFile(archive)
+	if err != nil {
+		return nil, err
+	}
+	sums := &provenance.SumCollection{
+		Files: map[string]string{
+			filepath.Base(archive): "sha256:" + digest,
+		},
+	}
+
+	data, err := yaml.Marshal(md)
+	if err != nil {
+		return nil, err
+	}
+	b := bytes.NewBuffer(data)
+	// YAML uses ---\n as a document start, which is not legal in a clear
+	// signed message. ...\n, the YAML document end marker, is used instead.
+	b.WriteString("\n...\n")
+
+	data, err = yaml.Marshal(sums)
+	if err != nil {
+		return nil, err
+	}
+	b.Write(data)
+	return b, nil
+}
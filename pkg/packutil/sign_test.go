@@ -0,0 +1,79 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Azure/draft/pkg/draft/pack"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/clearsign"
+	"k8s.io/helm/pkg/provenance"
+)
+
+func TestSign(t *testing.T) {
+	dir, err := ioutil.TempDir("", "draft-packs-sign-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	content := []byte("not really an archive")
+	archive := filepath.Join(dir, "golang-0.1.0.tgz")
+	if err := ioutil.WriteFile(archive, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entity, err := openpgp.NewEntity("Packager", "", "packager@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Sign(archive, &pack.Metadata{Name: "golang"}, &provenance.Signatory{}); err == nil {
+		t.Error("Expected an error without private key")
+	}
+
+	prov, err := Sign(archive, &pack.Metadata{Name: "golang", Version: "0.1.0"}, &provenance.Signatory{Entity: entity})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, _ := clearsign.Decode([]byte(prov))
+	if block == nil {
+		t.Fatalf("Expected a clear signed message, got %q", prov)
+	}
+	if _, err := openpgp.CheckDetachedSignature(openpgp.EntityList{entity}, strings.NewReader(string(block.Bytes)), block.ArmoredSignature.Body); err != nil {
+		t.Errorf("Invalid signature: %s", err)
+	}
+
+	sum := sha256.Sum256(content)
+	msg := string(block.Plaintext)
+	parts := strings.SplitN(msg, "\n...\n", 2)
+	if len(parts) != 2 {
+		t.Fatalf("Expected the metadata and the sums, got %q", msg)
+	}
+	if !strings.Contains(parts[0], "Name: golang") || !strings.Contains(parts[0], "Version: 0.1.0") {
+		t.Errorf("Expected the metadata, got %q", parts[0])
+	}
+	if expect := "golang-0.1.0.tgz: sha256:" + hex.EncodeToString(sum[:]); !strings.Contains(parts[1], expect) {
+		t.Errorf("Expected %q in the sums, got %q", expect, parts[1])
+	}
+}
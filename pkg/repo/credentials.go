@@ -0,0 +1,83 @@
+package repo
+
+import (
+	"fmt"
+
+	"github.com/rodcloutier/draft-packs/pkg/credentials"
+)
+
+// hasCredentials returns whether the credentials of e are set in the entry
+// itself.
+func (e *Entry) hasCredentials() bool {
+	return e.Username != "" || e.Password != "" || e.Token != ""
+}
+
+// credentialStore returns the credential store of e.
+func (e *Entry) credentialStore() (credentials.Store, error) {
+	if e.dir == "" {
+		return nil, fmt.Errorf("the credential store of repository %s has no directory", e.Name)
+	}
+	return credentials.NewStore(e.dir, e.Credentials), nil
+}
+
+// loadCredentials sets the credentials of e from its credential store, unless
+// they are already set. The credentials are stored under the name of the
+// repository.
+func (e *Entry) loadCredentials() error {
+	if e.Credentials == "" || e.hasCredentials() {
+		return nil
+	}
+	store, err := e.credentialStore()
+	if err != nil {
+		return err
+	}
+	c, err := store.Get(e.Name)
+	if err == credentials.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not get the credentials of repository %s: %s", e.Name, err)
+	}
+	e.Username = c.Username
+	e.Password = c.Password
+	e.Token = c.Token
+	return nil
+}
+
+// storeCredentials moves the credentials set in e to its credential store,
+// the encrypted store of the directory dir by default.
+func (e *Entry) storeCredentials(dir string) error {
+	e.dir = dir
+	if !e.hasCredentials() {
+		return nil
+	}
+	if e.Credentials == "" {
+		e.Credentials = credentials.DefaultStore
+	}
+	c := &credentials.Credentials{
+		Username: e.Username,
+		Password: e.Password,
+		Token:    e.Token,
+	}
+	store, err := e.credentialStore()
+	if err != nil {
+		return err
+	}
+	if err := store.Store(e.Name, c); err != nil {
+		return fmt.Errorf("could not store the credentials of repository %s: %s", e.Name, err)
+	}
+	e.Username, e.Password, e.Token = "", "", ""
+	return nil
+}
+
+// EraseCredentials removes the credentials of e from its credential store.
+func (e *Entry) EraseCredentials() error {
+	if e.Credentials == "" {
+		return nil
+	}
+	store, err := e.credentialStore()
+	if err != nil {
+		return err
+	}
+	return store.Erase(e.Name)
+}
@@ -0,0 +1,119 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rodcloutier/draft-packs/pkg/credentials"
+)
+
+func TestRepoFileCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "draft-packs-repo-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "repositories.yaml")
+
+	rf := NewRepoFile()
+	rf.Add(&Entry{Name: "private", URL: "https://example.com", Username: "user", Password: "secret"})
+	rf.Add(&Entry{Name: "public", URL: "https://public.example.com"})
+	if err := rf.WriteFile(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") || strings.Contains(string(data), "user") {
+		t.Errorf("Expected no credentials in the repositories file, got\n%s", data)
+	}
+
+	rf, err = LoadRepositoriesFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := rf.Repositories[0]
+	if e.Credentials != credentials.DefaultStore {
+		t.Errorf("Expected the credentials in the %s store, got %q", credentials.DefaultStore, e.Credentials)
+	}
+	if err := e.loadCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	if e.Username != "user" || e.Password != "secret" {
+		t.Errorf("Unexpected credentials %q/%q", e.Username, e.Password)
+	}
+	if rf.Repositories[1].Credentials != "" {
+		t.Errorf("Expected no credentials for the public repository")
+	}
+
+	if err := e.EraseCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	e.Username, e.Password = "", ""
+	if err := e.loadCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	if e.Username != "" {
+		t.Errorf("Expected the credentials to be erased")
+	}
+}
+
+func TestUpdateCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "draft-packs-repo-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "repositories.yaml")
+
+	rf := NewRepoFile()
+	rf.Add(&Entry{Name: "private", URL: "https://example.com", Token: "t0ken"})
+	rf.Add(&Entry{Name: "mirror", URL: "https://example.com", Token: "other"})
+	if err := rf.WriteFile(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+	rf, err = LoadRepositoriesFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The credentials are kept by an update without credentials
+	e := &Entry{Name: "private", URL: "https://example.com"}
+	rf.Update(e)
+	if err := e.loadCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	if e.Token != "t0ken" {
+		t.Errorf("Expected the credentials to be kept, got %q", e.Token)
+	}
+
+	// but not when the URL changes
+	e = &Entry{Name: "private", URL: "https://other.example.com"}
+	rf.Update(e)
+	if e.Credentials != "" {
+		t.Errorf("Expected no credentials for a new URL, got %q", e.Credentials)
+	}
+
+	// The credentials of repositories with the same URL are distinct
+	mirror := rf.Get("mirror")
+	if err := mirror.EraseCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	e = &Entry{Name: "private", URL: "https://example.com", Credentials: credentials.DefaultStore}
+	rf.Update(e)
+	if err := e.loadCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	if e.Token != "t0ken" {
+		t.Errorf("Expected the credentials of private to be kept, got %q", e.Token)
+	}
+
+	if err := (&Entry{Name: "fresh", Credentials: credentials.DefaultStore}).loadCredentials(); err == nil {
+		t.Error("Expected an error for a credential store without directory")
+	}
+}
@@ -0,0 +1,129 @@
+package repo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+
+	"github.com/Azure/draft/pkg/draft/pack"
+	"github.com/ghodss/yaml"
+
+	"github.com/rodcloutier/draft-packs/pkg/gitutil"
+	"github.com/rodcloutier/draft-packs/pkg/packutil"
+)
+
+// chartMetadata is the part of the Chart.yaml of a pack used to index it.
+type chartMetadata struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+}
+
+// cloner is implemented by the getters of the packs of git repositories, to
+// build the packs from the clone kept in the cache.
+type cloner interface {
+	SetClone(dir string)
+}
+
+// cloneDir returns the directory of the clone of the git repository of r,
+// kept next to the index cp.
+func (r *PackRepository) cloneDir(cp string) string {
+	return filepath.Join(filepath.Dir(cp), "git", r.Config.Name)
+}
+
+// downloadGitIndex clones the git repository of r, or fetches it if it was
+// already cloned, next to the index cp, and writes the index of the packs of
+// the commit of the repository URL in cp.
+func (r *PackRepository) downloadGitIndex(cp string) error {
+	if r.Config.Name == "" {
+		return fmt.Errorf("git repository %s must be added with 'draft packs repo add'", r.Config.URL)
+	}
+	remote, ref, err := gitutil.ParseURL(r.Config.URL)
+	if err != nil {
+		return err
+	}
+
+	dir := r.cloneDir(cp)
+	commit, err := gitutil.Sync(remote, ref, dir)
+	if err != nil {
+		return err
+	}
+
+	index, err := IndexGitCommit(dir, remote, commit)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(cp, data, 0644)
+}
+
+// IndexGitCommit generates the index of the packs of the commit of the git
+// repository dir, a clone of remote.
+//
+// Every directory holding a chart/Chart.yaml file is a pack, versioned by its
+// chart. The packs are named after their directory, unless named by their
+// metadata, and their digest is the one of the archive built from the commit.
+func IndexGitCommit(dir, remote, commit string) (*IndexFile, error) {
+	files, err := gitutil.ListFiles(dir, commit)
+	if err != nil {
+		return nil, err
+	}
+
+	index := NewIndexFile()
+	for _, f := range files {
+		if path.Base(f) != "Chart.yaml" || path.Base(path.Dir(f)) != "chart" {
+			continue
+		}
+		p := path.Dir(path.Dir(f))
+		if p == "." {
+			p = ""
+		}
+
+		md, err := gitPackMetadata(dir, remote, commit, p)
+		if err != nil {
+			return nil, err
+		}
+		archive, err := gitutil.Archive(dir, commit, p, gitutil.DirName(remote, p))
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(archive)
+		index.Add(md, gitutil.PackURL(remote, commit, p), "", hex.EncodeToString(sum[:]))
+	}
+	return index, nil
+}
+
+// gitPackMetadata returns the metadata of the pack found at p in the commit
+// of the git repository dir.
+func gitPackMetadata(dir, remote, commit, p string) (*pack.Metadata, error) {
+	data, err := gitutil.ReadFile(dir, commit, path.Join(p, "chart", "Chart.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	chart := &chartMetadata{}
+	if err := yaml.Unmarshal(data, chart); err != nil {
+		return nil, fmt.Errorf("invalid chart of pack %s: %s", p, err)
+	}
+	if chart.Version == "" {
+		return nil, fmt.Errorf("chart of pack %s has no version", p)
+	}
+
+	md := &pack.Metadata{
+		Name:        gitutil.DirName(remote, p),
+		Description: chart.Description,
+	}
+	if data, err := gitutil.ReadFile(dir, commit, path.Join(p, packutil.MetadataFile)); err == nil {
+		if err := yaml.Unmarshal(data, md); err != nil {
+			return nil, fmt.Errorf("invalid %s of pack %s: %s", packutil.MetadataFile, p, err)
+		}
+	}
+	// The version of the pack is the one of its chart
+	md.Version = chart.Version
+	return md, nil
+}
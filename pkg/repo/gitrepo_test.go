@@ -0,0 +1,135 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/helm/pkg/provenance"
+
+	. "github.com/rodcloutier/draft-packs/pkg/getter"
+	"github.com/rodcloutier/draft-packs/pkg/gitutil"
+)
+
+func TestDownloadGitIndex(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "draft-packs-gitrepo-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	work := filepath.Join(tmp, "work")
+	files := map[string]string{
+		"golang/chart/Chart.yaml":  "name: golang\nversion: 0.1.0\ndescription: Go apps\n",
+		"golang/Dockerfile":        "FROM golang",
+		"python/Pack.yaml":         "Name: py\nDescription: Python apps\n",
+		"python/chart/Chart.yaml":  "name: python\nversion: 1.2.0\n",
+		"notapack/chart/README.md": "not a chart",
+	}
+	for name, content := range files {
+		p := filepath.Join(work, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bare := filepath.Join(tmp, "packs.git")
+	for _, args := range [][]string{
+		{"init", "--quiet", work},
+		{"-C", work, "add", "."},
+		{"-C", work, "-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "packs"},
+		{"clone", "--quiet", "--bare", work, bare},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s: %s", args, err, out)
+		}
+	}
+
+	cache := filepath.Join(tmp, "cache")
+	if err := os.MkdirAll(cache, 0755); err != nil {
+		t.Fatal(err)
+	}
+	remote := "file://" + bare
+	r, err := NewRepository(&Entry{Name: "team", URL: gitutil.Prefix + remote, Cache: "team-index.yaml"}, AllProviders())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.DownloadIndexFile(cache); err != nil {
+		t.Fatal(err)
+	}
+
+	i, err := LoadIndexFile(filepath.Join(cache, "team-index.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(i.Entries) != 2 {
+		t.Fatalf("Expected 2 packs, got %v", i.Entries)
+	}
+
+	cv, err := i.Get("golang", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cv.Version != "0.1.0" || cv.Description != "Go apps" || cv.Digest == "" {
+		t.Errorf("Unexpected golang pack %+v", cv)
+	}
+	if !strings.HasPrefix(cv.URLs[0], gitutil.Prefix+remote+"#") || !strings.HasSuffix(cv.URLs[0], ":golang") {
+		t.Errorf("Unexpected URL %s", cv.URLs[0])
+	}
+
+	cv, err = i.Get("py", "1.2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cv.Description != "Python apps" {
+		t.Errorf("Expected the description of Pack.yaml, got %q", cv.Description)
+	}
+
+	// The archive built from the URL has the indexed digest
+	buf, err := r.Client.Get(cv.URLs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGitGetter(cv.URLs[0], "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := g.Get(cv.URLs[0]); err != nil || again.String() != buf.String() {
+		t.Errorf("Expected the same archive, got error %v", err)
+	}
+	f := filepath.Join(tmp, "python.tgz")
+	if err := ioutil.WriteFile(f, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if digest, _ := provenance.DigestFile(f); digest != cv.Digest {
+		t.Errorf("Expected digest %s, got %s", cv.Digest, digest)
+	}
+
+	// The clone is fetched again on update
+	if err := r.DownloadIndexFile(cache); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(cache, "git", "team", "HEAD")); err != nil {
+		t.Error(err)
+	}
+
+	// The packs are built from the cached clone, without the remote
+	if err := os.RemoveAll(bare); err != nil {
+		t.Fatal(err)
+	}
+	r, err = NewRepository(&Entry{Name: "team", URL: gitutil.Prefix + remote, Cache: filepath.Join(cache, "team-index.yaml")}, AllProviders())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached, err := r.Client.Get(cv.URLs[0]); err != nil || cached.String() != buf.String() {
+		t.Errorf("Expected the same archive from the cached clone, got error %v", err)
+	}
+	if _, err := r.Client.Get(cv.URLs[0] + ".prov"); err == nil {
+		t.Error("Expected no provenance file")
+	}
+}
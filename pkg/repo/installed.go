@@ -0,0 +1,121 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/facebookgo/atomicfile"
+	"github.com/ghodss/yaml"
+)
+
+// InstalledPack records where an installed pack comes from.
+type InstalledPack struct {
+	APIVersion string `json:"apiVersion"`
+	// Name is the name under which the pack is installed in $DRAFT_HOME/packs.
+	Name string `json:"name"`
+	// Repository is the name of the repository the pack was installed from.
+	// It is empty for packs installed from a local path or a URL.
+	Repository string `json:"repository,omitempty"`
+	// Reference is the pack reference given to install the pack.
+	Reference string `json:"reference"`
+	// Version is the version of the installed pack.
+	Version string `json:"version,omitempty"`
+	// URL is the location of the archive the pack was installed from.
+	URL string `json:"url,omitempty"`
+	// Digest is the SHA256 digest of the archive the pack was installed from.
+	Digest string `json:"digest,omitempty"`
+	// Commit is the git commit the pack was built from, for the packs of git
+	// repositories.
+	Commit string `json:"commit,omitempty"`
+	// Verified indicates that the provenance of the archive was verified.
+	Verified bool `json:"verified"`
+	// SignedBy is the identity of the signer when the pack was verified.
+	SignedBy string `json:"signedBy,omitempty"`
+	// Dependencies are the references of the packs required by the pack.
+	Dependencies []string `json:"dependencies,omitempty"`
+	// Installed is the time at which the pack was installed.
+	Installed time.Time `json:"installed"`
+}
+
+// NewInstalledPack creates the install record of the pack installed as name.
+//
+// Installed and APIVersion are automatically set.
+func NewInstalledPack(name string) *InstalledPack {
+	return &InstalledPack{
+		APIVersion: APIVersionV1,
+		Name:       name,
+		Installed:  time.Now(),
+	}
+}
+
+// LoadInstalledPack takes a file at the given path and returns an InstalledPack object
+func LoadInstalledPack(path string) (*InstalledPack, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p := &InstalledPack{}
+	if err := yaml.Unmarshal(b, p); err != nil {
+		return nil, err
+	}
+	if p.APIVersion == "" {
+		return p, ErrNoAPIVersion
+	}
+	return p, nil
+}
+
+// LoadInstalledPacks loads all the install records found in dir, sorted by name.
+//
+// A missing directory is not an error, it simply holds no records.
+func LoadInstalledPacks(dir string) ([]*InstalledPack, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*InstalledPack{}, nil
+		}
+		return nil, err
+	}
+
+	packs := []*InstalledPack{}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".yaml") {
+			continue
+		}
+		p, err := LoadInstalledPack(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		packs = append(packs, p)
+	}
+	sort.Slice(packs, func(i, j int) bool { return packs[i].Name < packs[j].Name })
+	return packs, nil
+}
+
+// WriteFile writes an install record to the given path.
+func (p *InstalledPack) WriteFile(path string, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := atomicfile.New(path, perm)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(p)
+	if err != nil {
+		f.Abort()
+		return err
+	}
+
+	if _, err := f.File.Write(data); err != nil {
+		f.Abort()
+		return err
+	}
+
+	return f.Close()
+}
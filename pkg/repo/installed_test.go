@@ -0,0 +1,52 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInstalledPacks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "draft-packs-installed-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	packs, err := LoadInstalledPacks(filepath.Join(dir, "nosuchdir"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packs) != 0 {
+		t.Errorf("Expected no records, got %d", len(packs))
+	}
+
+	for _, name := range []string{"stable-golang", "local"} {
+		p := NewInstalledPack(name)
+		p.Reference = name
+		if name == "stable-golang" {
+			p.Repository = "stable"
+			p.Reference = "stable/golang"
+			p.Version = "1.0.0"
+			p.Digest = "2b9d3a7c"
+		}
+		if err := p.WriteFile(filepath.Join(dir, name+".yaml"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	packs, err = LoadInstalledPacks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packs) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(packs))
+	}
+	if packs[0].Name != "local" || packs[1].Name != "stable-golang" {
+		t.Errorf("Unexpected records order %s, %s", packs[0].Name, packs[1].Name)
+	}
+	if p := packs[1]; p.Repository != "stable" || p.Reference != "stable/golang" || p.Version != "1.0.0" || p.Digest != "2b9d3a7c" {
+		t.Errorf("Unexpected record %+v", p)
+	}
+}
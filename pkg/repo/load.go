@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"path/filepath"
 
 	"github.com/ghodss/yaml"
 )
@@ -35,6 +36,7 @@ func LoadRepositoriesFile(path string) (*RepoFile, error) {
 			return nil, err
 		}
 		r := NewRepoFile()
+		r.dir = filepath.Dir(path)
 		for k, v := range m {
 			r.Add(&Entry{
 				Name:  k,
@@ -45,5 +47,9 @@ func LoadRepositoriesFile(path string) (*RepoFile, error) {
 		return r, ErrRepoOutOfDate
 	}
 
+	r.dir = filepath.Dir(path)
+	for _, e := range r.Repositories {
+		e.dir = r.dir
+	}
 	return r, nil
 }
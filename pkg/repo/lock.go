@@ -0,0 +1,98 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/facebookgo/atomicfile"
+	"github.com/ghodss/yaml"
+)
+
+// LockFileName is the default name of the lock file of a pack manifest.
+const LockFileName = "draft-packs.lock"
+
+// LockFile records the exact archives resolved for the packs of a manifest.
+type LockFile struct {
+	APIVersion string        `json:"apiVersion"`
+	Generated  time.Time     `json:"generated"`
+	Packs      []*LockedPack `json:"packs"`
+}
+
+// LockedPack is a pack resolved to an exact archive.
+type LockedPack struct {
+	// Name is the REPO/NAME reference of the pack.
+	Name string `json:"name"`
+	// As is the name under which the pack is installed, REPO-NAME by default.
+	As string `json:"as,omitempty"`
+	// Priority is the detection priority of the installed pack.
+	Priority int `json:"priority,omitempty"`
+	// Version is the exact version of the pack.
+	Version string `json:"version"`
+	// URL is the absolute location of the archive of the pack.
+	URL string `json:"url"`
+	// Digest is the SHA256 digest of the archive of the pack.
+	Digest string `json:"digest"`
+}
+
+// NewLockFile generates an empty lock file.
+//
+// Generated and APIVersion are automatically set.
+func NewLockFile() *LockFile {
+	return &LockFile{
+		APIVersion: APIVersionV1,
+		Generated:  time.Now(),
+		Packs:      []*LockedPack{},
+	}
+}
+
+// LoadLockFile takes a file at the given path and returns a LockFile object
+func LoadLockFile(path string) (*LockFile, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	l := &LockFile{}
+	if err := yaml.Unmarshal(b, l); err != nil {
+		return nil, err
+	}
+	if l.APIVersion == "" {
+		return l, ErrNoAPIVersion
+	}
+	return l, nil
+}
+
+// WriteFile writes a lock file to the given path.
+func (l *LockFile) WriteFile(path string, perm os.FileMode) error {
+	f, err := atomicfile.New(path, perm)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		f.Abort()
+		return err
+	}
+
+	if _, err := f.File.Write(data); err != nil {
+		f.Abort()
+		return err
+	}
+
+	return f.Close()
+}
+
+// Repository returns the name of the repository providing the pack.
+func (p *LockedPack) Repository() string {
+	return p.manifestPack().Repository()
+}
+
+// InstallName returns the name under which the pack is installed.
+func (p *LockedPack) InstallName() string {
+	return p.manifestPack().InstallName()
+}
+
+func (p *LockedPack) manifestPack() *ManifestPack {
+	return &ManifestPack{Name: p.Name, As: p.As}
+}
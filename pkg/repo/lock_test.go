@@ -0,0 +1,54 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLockFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "draft-packs-lock-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l := NewLockFile()
+	l.Packs = append(l.Packs, &LockedPack{
+		Name:     "stable/golang",
+		Priority: 10,
+		Version:  "1.2.3",
+		URL:      "https://example.com/packs/golang-1.2.3.tgz",
+		Digest:   "2b9d3a7c",
+	}, &LockedPack{
+		Name:    "stable/python",
+		As:      "py",
+		Version: "0.1.0",
+		URL:     "https://example.com/packs/python-0.1.0.tgz",
+		Digest:  "f00dcafe",
+	})
+
+	path := filepath.Join(dir, LockFileName)
+	if err := l.WriteFile(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadLockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Packs) != 2 {
+		t.Fatalf("Expected 2 packs, got %d", len(loaded.Packs))
+	}
+	golang := loaded.Packs[0]
+	if *golang != *l.Packs[0] {
+		t.Errorf("Expected %v, got %v", l.Packs[0], golang)
+	}
+	if golang.Repository() != "stable" || golang.InstallName() != "stable-golang" {
+		t.Errorf("Unexpected repository %s or name %s", golang.Repository(), golang.InstallName())
+	}
+	if name := loaded.Packs[1].InstallName(); name != "py" {
+		t.Errorf("Expected pack to be installed as py, got %s", name)
+	}
+}
@@ -0,0 +1,141 @@
+package repo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/ghodss/yaml"
+)
+
+// ManifestFile is the default name of the pack manifest.
+const ManifestFile = "draft-packs.yaml"
+
+// Manifest declares the repositories and the packs to set up in $DRAFT_HOME.
+type Manifest struct {
+	APIVersion   string                `json:"apiVersion"`
+	Repositories []*ManifestRepository `json:"repositories"`
+	Packs        []*ManifestPack       `json:"packs"`
+}
+
+// ManifestRepository is a pack repository declared by a manifest.
+type ManifestRepository struct {
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	CertFile string `json:"certFile,omitempty"`
+	KeyFile  string `json:"keyFile,omitempty"`
+	CAFile   string `json:"caFile,omitempty"`
+	// Verify and Fingerprints are the trust policy of the repository, see
+	// Entry.
+	Verify       string   `json:"verify,omitempty"`
+	Fingerprints []string `json:"fingerprints,omitempty"`
+}
+
+// Entry returns the repositories file entry of the repository.
+func (r *ManifestRepository) Entry() *Entry {
+	return &Entry{
+		Name:         r.Name,
+		URL:          r.URL,
+		CertFile:     r.CertFile,
+		KeyFile:      r.KeyFile,
+		CAFile:       r.CAFile,
+		Verify:       r.Verify,
+		Fingerprints: r.Fingerprints,
+	}
+}
+
+// ManifestPack is a pack declared by a manifest.
+type ManifestPack struct {
+	// Name is the REPO/NAME reference of the pack.
+	Name string `json:"name"`
+	// Version is a semantic versioning constraint the installed version must
+	// match. The latest version is installed when it is empty.
+	Version string `json:"version,omitempty"`
+	// As is the name under which the pack is installed, REPO-NAME by default.
+	As string `json:"as,omitempty"`
+	// Priority is the detection priority of the installed pack.
+	Priority int `json:"priority,omitempty"`
+}
+
+// LoadManifest takes a file at the given path and returns a Manifest object
+func LoadManifest(path string) (*Manifest, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{}
+	if err := yaml.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	if m.APIVersion == "" {
+		return m, ErrNoAPIVersion
+	}
+	return m, m.Validate()
+}
+
+// Validate checks that the declarations of the manifest are complete and
+// consistent.
+func (m *Manifest) Validate() error {
+	repos := map[string]bool{}
+	for _, r := range m.Repositories {
+		if r.Name == "" || r.URL == "" {
+			return fmt.Errorf("repository %q: name and url are required", r.Name)
+		}
+		if err := ValidateVerify(r.Verify); err != nil {
+			return fmt.Errorf("repository %q: %s", r.Name, err)
+		}
+		if repos[r.Name] {
+			return fmt.Errorf("repository %q is declared more than once", r.Name)
+		}
+		repos[r.Name] = true
+	}
+
+	names := map[string]bool{}
+	for _, p := range m.Packs {
+		if p.Repository() == "" || p.PackName() == "" {
+			return fmt.Errorf("pack %q: name must be a REPO/NAME reference", p.Name)
+		}
+		if p.Version != "" {
+			if _, err := semver.NewConstraint(p.Version); err != nil {
+				return fmt.Errorf("pack %q: invalid version constraint %q: %s", p.Name, p.Version, err)
+			}
+		}
+		if p.Priority < 0 || p.Priority > MaxPriority {
+			return fmt.Errorf("pack %q: priority must be between 0 and %d", p.Name, MaxPriority)
+		}
+		name := p.InstallName()
+		if strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+			return fmt.Errorf("pack %q: invalid name %q", p.Name, name)
+		}
+		if names[name] {
+			return fmt.Errorf("pack %q: more than one pack is installed as %s", p.Name, name)
+		}
+		names[name] = true
+	}
+	return nil
+}
+
+// Repository returns the name of the repository providing the pack.
+func (p *ManifestPack) Repository() string {
+	if i := strings.Index(p.Name, "/"); i != -1 {
+		return p.Name[:i]
+	}
+	return ""
+}
+
+// PackName returns the name of the pack in its repository.
+func (p *ManifestPack) PackName() string {
+	if i := strings.Index(p.Name, "/"); i != -1 {
+		return p.Name[i+1:]
+	}
+	return ""
+}
+
+// InstallName returns the name under which the pack is installed.
+func (p *ManifestPack) InstallName() string {
+	if p.As != "" {
+		return p.As
+	}
+	return strings.Replace(p.Name, "/", "-", -1)
+}
@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "draft-packs-manifest-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, ManifestFile)
+	data := `apiVersion: v1
+repositories:
+- name: stable
+  url: https://example.com/packs
+packs:
+- name: stable/golang
+  version: ^1.2.0
+  priority: 10
+- name: stable/python
+  as: py
+`
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := LoadManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Repositories) != 1 || m.Repositories[0].URL != "https://example.com/packs" {
+		t.Errorf("Unexpected repositories %v", m.Repositories)
+	}
+	if len(m.Packs) != 2 {
+		t.Fatalf("Expected 2 packs, got %d", len(m.Packs))
+	}
+	golang := m.Packs[0]
+	if golang.Repository() != "stable" || golang.PackName() != "golang" || golang.InstallName() != "stable-golang" {
+		t.Errorf("Unexpected pack %s: %s, %s, %s", golang.Name, golang.Repository(), golang.PackName(), golang.InstallName())
+	}
+	if golang.Version != "^1.2.0" || golang.Priority != 10 {
+		t.Errorf("Unexpected pack %s: %s, %d", golang.Name, golang.Version, golang.Priority)
+	}
+	if name := m.Packs[1].InstallName(); name != "py" {
+		t.Errorf("Expected pack to be installed as py, got %s", name)
+	}
+}
+
+func TestManifestValidate(t *testing.T) {
+	tests := []*Manifest{
+		{Repositories: []*ManifestRepository{{Name: "stable"}}},
+		{Repositories: []*ManifestRepository{{Name: "stable", URL: "a"}, {Name: "stable", URL: "b"}}},
+		{Repositories: []*ManifestRepository{{Name: "stable", URL: "a", Verify: "sometimes"}}},
+		{Packs: []*ManifestPack{{Name: "golang"}}},
+		{Packs: []*ManifestPack{{Name: "stable/golang", Version: "not a constraint"}}},
+		{Packs: []*ManifestPack{{Name: "stable/golang", Priority: 1000}}},
+		{Packs: []*ManifestPack{{Name: "stable/golang"}, {Name: "other/python", As: "stable-golang"}}},
+	}
+
+	for i, m := range tests {
+		if err := m.Validate(); err == nil {
+			t.Errorf("%d: expected a validation error", i)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package repo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/draft/pkg/draft/pack"
+	"github.com/Masterminds/semver"
+	"github.com/ghodss/yaml"
+
+	"github.com/rodcloutier/draft-packs/pkg/oci"
+)
+
+// registryClient is implemented by the getters of the packs kept in OCI
+// registries.
+type registryClient interface {
+	Registry() *oci.Client
+}
+
+// downloadOCIIndex writes in cp the index of the packs of the namespace of
+// the OCI registry of r.
+func (r *PackRepository) downloadOCIIndex(cp string) error {
+	rc, ok := r.Client.(registryClient)
+	if !ok {
+		return fmt.Errorf("Could not find a registry client for: %s", r.Config.URL)
+	}
+	index, err := IndexRegistry(rc.Registry(), r.Config.URL)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(cp, data, 0644)
+}
+
+// IndexRegistry generates the index of the packs of the namespace of an OCI
+// registry, given by repoURL such as oci://registry.example.com/packs.
+//
+// Every repository directly under the namespace is a pack, with a version
+// for each of its tags that is a semantic version. The URLs of the versions
+// are the references of their tag, and their digest is the one of their
+// archive. The tags that are not packs, such as images, are skipped. The
+// registry must list its repositories.
+func IndexRegistry(c *oci.Client, repoURL string) (*IndexFile, error) {
+	u, err := url.Parse(repoURL)
+	if err != nil || u.Scheme != oci.Scheme || u.Host == "" {
+		return nil, fmt.Errorf("invalid registry URL %s, expected oci://REGISTRY[/NAMESPACE]", repoURL)
+	}
+	prefix := strings.Trim(u.Path, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	repositories, err := c.Catalog(u.Host)
+	if err != nil {
+		return nil, fmt.Errorf("could not list the packs of %s: %s", repoURL, err)
+	}
+
+	index := NewIndexFile()
+	for _, name := range repositories {
+		if !strings.HasPrefix(name, prefix) || strings.Contains(name[len(prefix):], "/") {
+			continue
+		}
+		ref := &oci.Reference{Registry: u.Host, Repository: name}
+		tags, err := c.Tags(ref)
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range tags {
+			if _, err := semver.NewVersion(oci.VersionFromTag(tag)); err != nil {
+				continue
+			}
+			ref.Tag = tag
+			md, digest, err := registryPackMetadata(c, ref)
+			if err == oci.ErrNotPack {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			index.Add(md, ref.String(), "", digest)
+		}
+	}
+	return index, nil
+}
+
+// registryPackMetadata returns the metadata of the pack ref of a registry,
+// and the digest of its archive. It returns oci.ErrNotPack if ref is not a
+// pack.
+func registryPackMetadata(c *oci.Client, ref *oci.Reference) (*pack.Metadata, string, error) {
+	manifest, _, err := c.Manifest(ref)
+	if err != nil {
+		return nil, "", err
+	}
+	config, err := c.Blob(ref, &manifest.Config)
+	if err != nil {
+		return nil, "", err
+	}
+	md := &pack.Metadata{}
+	if err := json.Unmarshal(config, md); err != nil {
+		return nil, "", fmt.Errorf("invalid metadata of pack %s: %s", ref, err)
+	}
+	// The pack is named after its repository and versioned by its tag
+	md.Name = ref.Name()
+	md.Version = oci.VersionFromTag(ref.Tag)
+
+	digest := manifest.Layer(oci.ArchiveMediaType).Digest
+	return md, strings.TrimPrefix(digest, "sha256:"), nil
+}
@@ -0,0 +1,92 @@
+package repo
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/draft/pkg/draft/pack"
+
+	. "github.com/rodcloutier/draft-packs/pkg/getter"
+	"github.com/rodcloutier/draft-packs/pkg/oci"
+	"github.com/rodcloutier/draft-packs/pkg/oci/ocitest"
+)
+
+func TestDownloadOCIIndex(t *testing.T) {
+	registry := ocitest.NewRegistry()
+	defer registry.Stop()
+	registry.SetTokenAuth("user", "secret")
+
+	c := oci.NewClient(nil)
+	c.Username, c.Password = "user", "secret"
+	for _, p := range []struct{ repository, tag string }{
+		{"packs/golang", "0.1.0"},
+		{"packs/golang", "0.2.0_build.1"},
+		{"packs/golang", "latest"},
+		{"packs/nested/python", "1.0.0"},
+		{"other/ruby", "1.0.0"},
+	} {
+		config, err := json.Marshal(&pack.Metadata{Name: "unused", Description: "Go apps"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ref := &oci.Reference{Registry: registry.Host(), Repository: p.repository, Tag: p.tag}
+		if _, err := c.Push(ref, config, []byte("archive "+p.tag), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Images are not packs, and lists span several pages
+	registry.PushImage("packs/web", "1.0.0")
+	registry.PushImage("packs/golang", "0.3.0")
+	registry.SetPageSize(1)
+
+	tmp, err := ioutil.TempDir("", "draft-packs-ocirepo-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	repoURL := "oci://" + registry.Host() + "/packs"
+	e := &Entry{Name: "registry", URL: repoURL, Cache: "registry-index.yaml", Username: "user", Password: "secret"}
+	r, err := NewRepository(e, AllProviders())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.DownloadIndexFile(tmp); err != nil {
+		t.Fatal(err)
+	}
+
+	i, err := LoadIndexFile(filepath.Join(tmp, "registry-index.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(i.Entries) != 1 || len(i.Entries["golang"]) != 2 {
+		t.Fatalf("Expected 2 versions of golang, got %v", i.Entries)
+	}
+	i.SortEntries()
+
+	cv, err := i.Get("golang", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cv.Version != "0.2.0+build.1" || cv.Description != "Go apps" {
+		t.Errorf("Unexpected golang pack %+v", cv)
+	}
+	if expect := repoURL + "/golang:0.2.0_build.1"; cv.URLs[0] != expect {
+		t.Errorf("Expected URL %s, got %s", expect, cv.URLs[0])
+	}
+	if expect := oci.Digest([]byte("archive 0.2.0_build.1")); "sha256:"+cv.Digest != expect {
+		t.Errorf("Expected digest %s, got %s", expect, cv.Digest)
+	}
+
+	buf, err := r.Client.Get(cv.URLs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "archive 0.2.0_build.1" {
+		t.Errorf("Unexpected archive %q", buf.String())
+	}
+}
@@ -9,6 +9,9 @@ import (
 	"strings"
 
 	"k8s.io/helm/pkg/getter"
+
+	"github.com/rodcloutier/draft-packs/pkg/gitutil"
+	"github.com/rodcloutier/draft-packs/pkg/oci"
 )
 
 // PackRepository represents a pack repository
@@ -27,6 +30,32 @@ type Entry struct {
 	CertFile string `json:"certFile"`
 	KeyFile  string `json:"keyFile"`
 	CAFile   string `json:"caFile"`
+	// Username and Password are the credentials used for basic
+	// authentication, Token is sent as a bearer token instead. They are moved
+	// to the credential store of the entry when the repositories file is
+	// written.
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Token    string `json:"token,omitempty"`
+	// Credentials is the name of the credential store holding the
+	// credentials of the repository, see credentials.NewStore.
+	Credentials string `json:"credentials,omitempty"`
+	// Verify is the verification policy of the packs downloaded from the
+	// repository, one of never, if-possible or always.
+	Verify string `json:"verify,omitempty"`
+	// Fingerprints restricts the keys accepted to sign the packs of the
+	// repository. Any key of the keyring is accepted when empty.
+	Fingerprints []string `json:"fingerprints,omitempty"`
+
+	// dir is the directory of the repositories file of the entry, where the
+	// encrypted credential store is kept.
+	dir string
+}
+
+// authenticator is implemented by the getters supporting credentials.
+type authenticator interface {
+	SetCredentials(username, password string)
+	SetToken(token string)
 }
 
 // NewChartRepository constructs PackRepository
@@ -44,18 +73,42 @@ func NewRepository(cfg *Entry, getters getter.Providers) (*PackRepository, error
 	if err != nil {
 		return nil, fmt.Errorf("Could not construct protocol handler for: %s", u.Scheme)
 	}
+	if err := cfg.loadCredentials(); err != nil {
+		return nil, err
+	}
+	if a, ok := client.(authenticator); ok {
+		if cfg.Username != "" || cfg.Password != "" {
+			a.SetCredentials(cfg.Username, cfg.Password)
+		}
+		if cfg.Token != "" {
+			a.SetToken(cfg.Token)
+		}
+	} else if cfg.Username != "" || cfg.Password != "" || cfg.Token != "" {
+		return nil, fmt.Errorf("Credentials are not supported for: %s", u.Scheme)
+	}
 
-	return &PackRepository{
+	r := &PackRepository{
 		Config:    cfg,
 		IndexFile: NewIndexFile(),
 		Client:    client,
-	}, nil
+	}
+	if c, ok := client.(cloner); ok && cfg.Name != "" && filepath.IsAbs(cfg.Cache) {
+		c.SetClone(r.cloneDir(cfg.Cache))
+	}
+	return r, nil
 }
 
 // DownloadIndexFile fetches the index from a repository.
 func (r *PackRepository) DownloadIndexFile(cachePath string) error {
 	var indexURL string
 
+	if gitutil.IsGitURL(r.Config.URL) {
+		return r.downloadGitIndex(r.cachePath(cachePath))
+	}
+	if strings.HasPrefix(r.Config.URL, oci.Scheme+"://") {
+		return r.downloadOCIIndex(r.cachePath(cachePath))
+	}
+
 	indexURL = strings.TrimSuffix(r.Config.URL, "/") + "/index.yaml"
 	resp, err := r.Client.Get(indexURL)
 	if err != nil {
@@ -71,6 +124,11 @@ func (r *PackRepository) DownloadIndexFile(cachePath string) error {
 		return err
 	}
 
+	return ioutil.WriteFile(r.cachePath(cachePath), index, 0644)
+}
+
+// cachePath returns the path of the cached index of the repository.
+func (r *PackRepository) cachePath(cachePath string) string {
 	// In Helm 2.2.0 the config.cache was accidentally switched to an absolute
 	// path, which broke backward compatibility. This fixes it by prepending a
 	// global cache path to relative paths.
@@ -81,8 +139,7 @@ func (r *PackRepository) DownloadIndexFile(cachePath string) error {
 	if !filepath.IsAbs(cp) {
 		cp = filepath.Join(cachePath, cp)
 	}
-
-	return ioutil.WriteFile(cp, index, 0644)
+	return cp
 }
 
 // FindPackInRepoURL finds pack in pack repository pointed by repoURL
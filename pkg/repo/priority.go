@@ -0,0 +1,65 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+)
+
+// MaxPriority is the highest priority that can be given to an installed pack.
+const MaxPriority = 999
+
+// ErrPackNotInstalled indicates that a pack is not installed.
+var ErrPackNotInstalled = errors.New("pack is not installed")
+
+var packDirRe = regexp.MustCompile(`^(\d{3})-(.+)$`)
+
+// PackDirName returns the name of the directory in which the pack name is
+// installed with the given priority.
+//
+// Draft reads the installed packs in the alphabetical order of their
+// directories. The priority is written as a zero padded prefix so that packs
+// with a lower priority are detected first, and before the packs without
+// priority. A priority of 0 means no priority.
+func PackDirName(name string, priority int) string {
+	if priority <= 0 {
+		return name
+	}
+	return fmt.Sprintf("%03d-%s", priority, name)
+}
+
+// ParsePackDirName returns the name and the priority of the pack installed in
+// the directory dir.
+func ParsePackDirName(dir string) (string, int) {
+	m := packDirRe.FindStringSubmatch(dir)
+	if m == nil {
+		return dir, 0
+	}
+	priority, err := strconv.Atoi(m[1])
+	if err != nil || priority == 0 {
+		return dir, 0
+	}
+	return m[2], priority
+}
+
+// FindPackDir returns the directory of packsDir in which the pack name is
+// installed, whatever its priority.
+//
+// ErrPackNotInstalled is returned if the pack is not installed.
+func FindPackDir(packsDir, name string) (string, error) {
+	files, err := ioutil.ReadDir(packsDir)
+	if err != nil {
+		return "", err
+	}
+	for _, f := range files {
+		if !f.IsDir() {
+			continue
+		}
+		if n, _ := ParsePackDirName(f.Name()); n == name || f.Name() == name {
+			return f.Name(), nil
+		}
+	}
+	return "", ErrPackNotInstalled
+}
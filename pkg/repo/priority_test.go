@@ -0,0 +1,63 @@
+package repo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPackDirName(t *testing.T) {
+	tests := []struct {
+		name     string
+		priority int
+		dir      string
+	}{
+		{"golang", 0, "golang"},
+		{"golang", 10, "010-golang"},
+		{"stable-golang", 999, "999-stable-golang"},
+	}
+
+	for _, tt := range tests {
+		if dir := PackDirName(tt.name, tt.priority); dir != tt.dir {
+			t.Errorf("PackDirName(%q, %d): expected %q, got %q", tt.name, tt.priority, tt.dir, dir)
+		}
+		if name, priority := ParsePackDirName(tt.dir); name != tt.name || priority != tt.priority {
+			t.Errorf("ParsePackDirName(%q): expected %q, %d, got %q, %d", tt.dir, tt.name, tt.priority, name, priority)
+		}
+	}
+
+	for _, dir := range []string{"000-golang", "10-golang", "0100-golang"} {
+		if name, priority := ParsePackDirName(dir); name != dir || priority != 0 {
+			t.Errorf("ParsePackDirName(%q): expected no priority, got %q, %d", dir, name, priority)
+		}
+	}
+}
+
+func TestFindPackDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "draft-packs-priority-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, d := range []string{"010-golang", "python"} {
+		if err := os.Mkdir(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, expect := range map[string]string{"golang": "010-golang", "python": "python"} {
+		d, err := FindPackDir(dir, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d != expect {
+			t.Errorf("FindPackDir(%q): expected %q, got %q", name, expect, d)
+		}
+	}
+
+	if _, err := FindPackDir(dir, "ruby"); err != ErrPackNotInstalled {
+		t.Errorf("Expected ErrPackNotInstalled, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package repo
 
 import (
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/facebookgo/atomicfile"
@@ -13,6 +14,10 @@ type RepoFile struct {
 	APIVersion   string    `json:"apiVersion"`
 	Generated    time.Time `json:"generated"`
 	Repositories []*Entry  `json:"repositories"`
+
+	// dir is the directory of the repositories file, where the encrypted
+	// credential store is kept.
+	dir string
 }
 
 // NewRepoFile generates an empty repositories file.
@@ -28,9 +33,24 @@ func NewRepoFile() *RepoFile {
 
 // Add adds one or more repo entries to a repo file.
 func (r *RepoFile) Add(re ...*Entry) {
+	for _, e := range re {
+		if e.dir == "" {
+			e.dir = r.dir
+		}
+	}
 	r.Repositories = append(r.Repositories, re...)
 }
 
+// Get returns the entry of the repository name, or nil if there is none.
+func (r *RepoFile) Get(name string) *Entry {
+	for _, rf := range r.Repositories {
+		if rf.Name == name {
+			return rf
+		}
+	}
+	return nil
+}
+
 // Has returns true if the given name is already a repository name.
 func (r *RepoFile) Has(name string) bool {
 	for _, rf := range r.Repositories {
@@ -58,11 +78,20 @@ func (r *RepoFile) Remove(name string) bool {
 
 // Update attempts to replace one or more repo entries in a repo file. If an
 // entry with the same name doesn't exist in the repo file it will add it.
+//
+// A replaced entry given without credentials keeps the credentials of the
+// existing entry, unless its URL changed.
 func (r *RepoFile) Update(re ...*Entry) {
 	for _, target := range re {
 		found := false
 		for j, repo := range r.Repositories {
 			if repo.Name == target.Name {
+				if target.dir == "" {
+					target.dir = repo.dir
+				}
+				if target.Credentials == "" && !target.hasCredentials() && target.URL == repo.URL {
+					target.Credentials = repo.Credentials
+				}
 				r.Repositories[j] = target
 				found = true
 				break
@@ -75,7 +104,16 @@ func (r *RepoFile) Update(re ...*Entry) {
 }
 
 // WriteFile writes a repositories file to the given path.
+//
+// The credentials of the entries are moved to their credential store, so that
+// the repositories file holds no secret.
 func (r *RepoFile) WriteFile(path string, perm os.FileMode) error {
+	for _, e := range r.Repositories {
+		if err := e.storeCredentials(filepath.Dir(path)); err != nil {
+			return err
+		}
+	}
+
 	f, err := atomicfile.New(path, perm)
 	if err != nil {
 		return err
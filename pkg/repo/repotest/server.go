@@ -78,8 +78,22 @@ func NewServer(docroot string) *Server {
 
 // Server is an implementation of a repository server for testing.
 type Server struct {
-	docroot string
-	srv     *httptest.Server
+	docroot  string
+	srv      *httptest.Server
+	username string
+	password string
+	token    string
+}
+
+// SetBasicAuth protects the server with basic authentication.
+func (s *Server) SetBasicAuth(username, password string) {
+	s.username = username
+	s.password = password
+}
+
+// SetToken protects the server with a bearer token.
+func (s *Server) SetToken(token string) {
+	s.token = token
 }
 
 // Root gets the docroot for the server.
@@ -129,7 +143,28 @@ func (s *Server) CreateIndex() error {
 }
 
 func (s *Server) start() {
-	s.srv = httptest.NewServer(http.FileServer(http.Dir(s.docroot)))
+	files := http.FileServer(http.Dir(s.docroot))
+	s.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="repotest"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		files.ServeHTTP(w, r)
+	}))
+}
+
+// authorized returns whether the request r carries the credentials required
+// by the server, if any.
+func (s *Server) authorized(r *http.Request) bool {
+	if s.token != "" {
+		return r.Header.Get("Authorization") == "Bearer "+s.token
+	}
+	if s.username != "" || s.password != "" {
+		username, password, ok := r.BasicAuth()
+		return ok && username == s.username && password == s.password
+	}
+	return true
 }
 
 // Stop stops the server and closes all connections.
@@ -103,6 +103,41 @@ func TestServer(t *testing.T) {
 	}
 }
 
+func TestServerAuth(t *testing.T) {
+	srv, tdir, err := NewTempServer("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		srv.Stop()
+		os.RemoveAll(tdir)
+	}()
+	srv.SetBasicAuth("user", "secret")
+
+	res, err := http.Get(srv.URL() + "/index.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %d", res.StatusCode)
+	}
+
+	req, err := http.NewRequest("GET", srv.URL()+"/index.yaml-nosuchthing", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SetBasicAuth("user", "secret")
+	res, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", res.StatusCode)
+	}
+}
+
 func TestNewTempServer(t *testing.T) {
 	srv, tdir, err := NewTempServer("testdata/examplepack.tgz")
 	if err != nil {
@@ -0,0 +1,45 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The verification policies of a repository.
+const (
+	VerifyNever      = "never"
+	VerifyIfPossible = "if-possible"
+	VerifyAlways     = "always"
+)
+
+// ValidateVerify returns an error if policy is not a verification policy. An
+// empty policy is the same as never.
+func ValidateVerify(policy string) error {
+	switch policy {
+	case "", VerifyNever, VerifyIfPossible, VerifyAlways:
+		return nil
+	}
+	return fmt.Errorf("unknown verification policy %q, expected one of: %s, %s, %s", policy, VerifyNever, VerifyIfPossible, VerifyAlways)
+}
+
+// NormalizeFingerprint returns the fingerprint fp in upper case hexadecimal,
+// without spaces nor 0x prefix.
+func NormalizeFingerprint(fp string) string {
+	fp = strings.ToUpper(strings.Replace(fp, " ", "", -1))
+	return strings.TrimPrefix(fp, "0X")
+}
+
+// Trusts returns whether the packs of the repository can be signed by the key
+// with the fingerprint fp.
+func (e *Entry) Trusts(fp string) bool {
+	if len(e.Fingerprints) == 0 {
+		return true
+	}
+	fp = NormalizeFingerprint(fp)
+	for _, f := range e.Fingerprints {
+		if NormalizeFingerprint(f) == fp {
+			return true
+		}
+	}
+	return false
+}
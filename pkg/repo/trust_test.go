@@ -0,0 +1,29 @@
+package repo
+
+import "testing"
+
+func TestValidateVerify(t *testing.T) {
+	for _, policy := range []string{"", VerifyNever, VerifyIfPossible, VerifyAlways} {
+		if err := ValidateVerify(policy); err != nil {
+			t.Errorf("Unexpected error for %q: %s", policy, err)
+		}
+	}
+	if err := ValidateVerify("sometimes"); err == nil {
+		t.Error("Expected an error for an unknown policy")
+	}
+}
+
+func TestEntryTrusts(t *testing.T) {
+	e := &Entry{Name: "stable"}
+	if !e.Trusts("ABCD") {
+		t.Error("Expected any key to be trusted without fingerprints")
+	}
+
+	e.Fingerprints = []string{"0xabcd ef01 2345"}
+	if !e.Trusts("ABCDEF012345") {
+		t.Error("Expected the pinned key to be trusted")
+	}
+	if e.Trusts("ABCDEF012346") {
+		t.Error("Expected another key not to be trusted")
+	}
+}
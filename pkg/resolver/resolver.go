@@ -0,0 +1,277 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package resolver resolves the dependencies between packs against the
+// repository indexes.
+package resolver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+
+	"github.com/rodcloutier/draft-packs/pkg/packutil"
+	"github.com/rodcloutier/draft-packs/pkg/repo"
+)
+
+// maxSteps bounds the number of selections made while resolving, in case
+// the selected versions keep changing.
+const maxSteps = 1000
+
+// Resolved is a version of a pack selected to satisfy dependencies.
+type Resolved struct {
+	// Name is the REPO/NAME reference of the pack.
+	Name string
+	// Version is the selected version of the pack.
+	Version *repo.PackVersion
+	// Dependencies are the references of the packs required by this version.
+	Dependencies []string
+}
+
+// Resolver resolves dependencies against the repository indexes.
+type Resolver struct {
+	// Index returns the index of the named repository.
+	Index func(name string) (*repo.IndexFile, error)
+	// Dependencies returns the dependencies of the version v of the pack ref.
+	Dependencies func(ref string, v *repo.PackVersion) ([]*packutil.Dependency, error)
+	// Installed maps the references of the packs already installed, or
+	// being installed, to their version. These packs are not resolved again:
+	// their version must satisfy the dependencies on them.
+	Installed map[string]string
+
+	indexes map[string]*repo.IndexFile
+}
+
+// Resolve returns the packs required, directly or not, by the packs in
+// requirers, which maps references to their dependencies.
+//
+// Every pack is returned after its own dependencies. The packs in Installed
+// are not returned. An error is returned when no version satisfies all the
+// dependencies on a pack, or when the dependencies form a cycle, including
+// through the packs in requirers.
+func (r *Resolver) Resolve(requirers map[string][]*packutil.Dependency) ([]*Resolved, error) {
+	r.indexes = map[string]*repo.IndexFile{}
+
+	// constraints maps a reference to the constraints set by its requirers
+	constraints := map[string]map[string]string{}
+	chosen := map[string]*Resolved{}
+	pending := []string{}
+
+	add := func(requirer string, deps []*packutil.Dependency) {
+		for _, d := range deps {
+			if constraints[d.Name] == nil {
+				constraints[d.Name] = map[string]string{}
+			}
+			constraints[d.Name][requirer] = d.Version
+			pending = append(pending, d.Name)
+		}
+	}
+	drop := func(requirer string) {
+		for ref, cs := range constraints {
+			if _, ok := cs[requirer]; ok {
+				delete(cs, requirer)
+				pending = append(pending, ref)
+			}
+		}
+	}
+
+	names := []string{}
+	for name := range requirers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		add(name, requirers[name])
+	}
+
+	for steps := 0; len(pending) > 0; steps++ {
+		if steps > maxSteps {
+			return nil, fmt.Errorf("unable to resolve dependencies in %d steps", maxSteps)
+		}
+		ref := pending[0]
+		pending = pending[1:]
+
+		cs := constraints[ref]
+		if len(cs) == 0 {
+			// No longer required
+			if chosen[ref] != nil {
+				delete(chosen, ref)
+				drop(ref)
+			}
+			continue
+		}
+
+		if version, ok := r.Installed[ref]; ok {
+			if err := check(ref, version, cs); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		pv, err := r.pick(ref, cs)
+		if err != nil {
+			return nil, err
+		}
+		if res := chosen[ref]; res != nil {
+			if res.Version.Version == pv.Version {
+				continue
+			}
+			drop(ref)
+		}
+
+		deps, err := r.Dependencies(ref, pv)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get the dependencies of %s %s: %s", ref, pv.Version, err)
+		}
+		res := &Resolved{Name: ref, Version: pv}
+		for _, d := range deps {
+			res.Dependencies = append(res.Dependencies, d.Name)
+		}
+		chosen[ref] = res
+		add(ref, deps)
+	}
+
+	return order(chosen, requirers)
+}
+
+// pick returns the latest version of the pack ref satisfying the constraints
+// cs.
+func (r *Resolver) pick(ref string, cs map[string]string) (*repo.PackVersion, error) {
+	parts := strings.SplitN(ref, "/", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("dependency %q must be a REPO/NAME reference", ref)
+	}
+	index, ok := r.indexes[parts[0]]
+	if !ok {
+		var err error
+		if index, err = r.Index(parts[0]); err != nil {
+			return nil, fmt.Errorf("unable to load the index of repository %s: %s", parts[0], err)
+		}
+		index.SortEntries()
+		r.indexes[parts[0]] = index
+	}
+
+	versions, ok := index.Entries[parts[1]]
+	if !ok || len(versions) == 0 {
+		return nil, fmt.Errorf("pack %s not found in repository %s", parts[1], parts[0])
+	}
+	for _, pv := range versions {
+		if check(ref, pv.Version, cs) == nil {
+			return pv, nil
+		}
+	}
+	return nil, fmt.Errorf("conflict: no version of %s satisfies %s", ref, describe(cs))
+}
+
+// check verifies that version satisfies the constraints cs on the pack ref.
+func check(ref, version string, cs map[string]string) error {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return fmt.Errorf("%s has an invalid version %q", ref, version)
+	}
+	for _, c := range cs {
+		if c == "" {
+			continue
+		}
+		constraint, err := semver.NewConstraint(c)
+		if err != nil {
+			return err
+		}
+		if !constraint.Check(v) {
+			return fmt.Errorf("conflict: %s %s does not satisfy %s", ref, version, describe(cs))
+		}
+	}
+	return nil
+}
+
+// describe lists the constraints and the packs setting them.
+func describe(cs map[string]string) string {
+	s := []string{}
+	for requirer, c := range cs {
+		if c == "" {
+			c = "*"
+		}
+		s = append(s, fmt.Sprintf("%s (required by %s)", c, requirer))
+	}
+	sort.Strings(s)
+	return strings.Join(s, ", ")
+}
+
+// order sorts the chosen packs so that every pack comes after its
+// dependencies, failing if the dependencies of the chosen packs and of the
+// requirers form a cycle. The requirers are not returned.
+func order(chosen map[string]*Resolved, requirers map[string][]*packutil.Dependency) ([]*Resolved, error) {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	ordered := []*Resolved{}
+
+	dependencies := func(ref string) ([]string, bool) {
+		if res, ok := chosen[ref]; ok {
+			return res.Dependencies, true
+		}
+		deps, ok := requirers[ref]
+		names := []string{}
+		for _, d := range deps {
+			names = append(names, d.Name)
+		}
+		return names, ok
+	}
+
+	var visit func(ref string, path []string) error
+	visit = func(ref string, path []string) error {
+		deps, ok := dependencies(ref)
+		if !ok {
+			return nil
+		}
+		switch state[ref] {
+		case visiting:
+			return fmt.Errorf("dependency cycle: %s", strings.Join(append(path, ref), " -> "))
+		case visited:
+			return nil
+		}
+		state[ref] = visiting
+		for _, d := range deps {
+			if err := visit(d, append(path, ref)); err != nil {
+				return err
+			}
+		}
+		state[ref] = visited
+		if res, ok := chosen[ref]; ok {
+			ordered = append(ordered, res)
+		}
+		return nil
+	}
+
+	refs := []string{}
+	for ref := range chosen {
+		refs = append(refs, ref)
+	}
+	for ref := range requirers {
+		if _, ok := chosen[ref]; !ok {
+			refs = append(refs, ref)
+		}
+	}
+	sort.Strings(refs)
+	for _, ref := range refs {
+		if err := visit(ref, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
@@ -0,0 +1,153 @@
+// Copyright © 2017 Rodrigue Cloutier <rodcloutier@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resolver
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Azure/draft/pkg/draft/pack"
+
+	"github.com/rodcloutier/draft-packs/pkg/packutil"
+	"github.com/rodcloutier/draft-packs/pkg/repo"
+)
+
+// testRepo is a repository whose packs have the given dependencies, keyed by
+// name-version.
+type testRepo struct {
+	versions map[string][]string
+	deps     map[string][]*packutil.Dependency
+}
+
+func (tr *testRepo) resolver(installed map[string]string) *Resolver {
+	return &Resolver{
+		Index: func(name string) (*repo.IndexFile, error) {
+			if name != "stable" {
+				return nil, errors.New("no such repository")
+			}
+			i := repo.NewIndexFile()
+			for n, versions := range tr.versions {
+				for _, v := range versions {
+					i.Add(&pack.Metadata{Name: n, Version: v}, n+"-"+v+".tgz", "", "")
+				}
+			}
+			return i, nil
+		},
+		Dependencies: func(ref string, v *repo.PackVersion) ([]*packutil.Dependency, error) {
+			return tr.deps[strings.TrimPrefix(ref, "stable/")+"-"+v.Version], nil
+		},
+		Installed: installed,
+	}
+}
+
+func dep(name, version string) *packutil.Dependency {
+	return &packutil.Dependency{Name: name, Version: version}
+}
+
+func TestResolve(t *testing.T) {
+	tr := &testRepo{
+		versions: map[string][]string{
+			"base":  {"1.0.0", "1.2.0", "2.0.0"},
+			"grpc":  {"1.0.0", "1.1.0"},
+			"proto": {"0.1.0"},
+		},
+		deps: map[string][]*packutil.Dependency{
+			"grpc-1.1.0": {dep("stable/base", "^1.0.0"), dep("stable/proto", "")},
+			"grpc-1.0.0": {dep("stable/base", "^1.0.0")},
+		},
+	}
+
+	resolved, err := tr.resolver(nil).Resolve(map[string][]*packutil.Dependency{
+		"go-grpc": {dep("stable/grpc", ""), dep("stable/base", "<1.2.0")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := []string{}
+	for _, r := range resolved {
+		got = append(got, r.Name+"@"+r.Version.Version)
+	}
+	expect := "stable/base@1.0.0 stable/proto@0.1.0 stable/grpc@1.1.0"
+	if strings.Join(got, " ") != expect {
+		t.Errorf("Expected %s, got %s", expect, strings.Join(got, " "))
+	}
+
+	// Installed packs are not resolved again
+	resolved, err = tr.resolver(map[string]string{"stable/base": "1.1.0", "stable/proto": "0.1.0"}).Resolve(map[string][]*packutil.Dependency{
+		"go-grpc": {dep("stable/grpc", "")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resolved) != 1 || resolved[0].Name != "stable/grpc" {
+		t.Errorf("Expected only stable/grpc to be resolved, got %v", resolved)
+	}
+}
+
+func TestResolveConflict(t *testing.T) {
+	tr := &testRepo{
+		versions: map[string][]string{
+			"base": {"1.0.0", "2.0.0"},
+			"grpc": {"1.0.0"},
+		},
+		deps: map[string][]*packutil.Dependency{
+			"grpc-1.0.0": {dep("stable/base", "^2.0.0")},
+		},
+	}
+
+	_, err := tr.resolver(nil).Resolve(map[string][]*packutil.Dependency{
+		"go-grpc": {dep("stable/grpc", ""), dep("stable/base", "^1.0.0")},
+	})
+	if err == nil || !strings.Contains(err.Error(), "conflict") {
+		t.Errorf("Expected a conflict, got %v", err)
+	}
+
+	_, err = tr.resolver(map[string]string{"stable/base": "1.0.0"}).Resolve(map[string][]*packutil.Dependency{
+		"go-grpc": {dep("stable/grpc", "")},
+	})
+	if err == nil || !strings.Contains(err.Error(), "conflict") {
+		t.Errorf("Expected a conflict with the installed pack, got %v", err)
+	}
+}
+
+func TestResolveCycle(t *testing.T) {
+	tr := &testRepo{
+		versions: map[string][]string{
+			"a": {"1.0.0"},
+			"b": {"1.0.0"},
+		},
+		deps: map[string][]*packutil.Dependency{
+			"a-1.0.0": {dep("stable/b", "")},
+			"b-1.0.0": {dep("stable/a", "")},
+		},
+	}
+
+	_, err := tr.resolver(nil).Resolve(map[string][]*packutil.Dependency{
+		"root": {dep("stable/a", "")},
+	})
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("Expected a cycle, got %v", err)
+	}
+
+	// The cycle goes through a pack being installed
+	_, err = tr.resolver(map[string]string{"stable/a": "1.0.0"}).Resolve(map[string][]*packutil.Dependency{
+		"stable/a": {dep("stable/b", "")},
+	})
+	if err == nil || !strings.Contains(err.Error(), "stable/a -> stable/b -> stable/a") {
+		t.Errorf("Expected a cycle through stable/a, got %v", err)
+	}
+}